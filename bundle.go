@@ -0,0 +1,174 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/john-craig/smartctl_ssacli_exporter/collector"
+	"github.com/john-craig/smartctl_ssacli_exporter/exporter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// bundleManifest describes the content of a support bundle
+type bundleManifest struct {
+	CreatedAt    time.Time         `json:"created_at"`
+	Hostname     string            `json:"hostname"`
+	ToolVersions map[string]string `json:"tool_versions"`
+	Commands     []bundleCommand   `json:"commands"`
+}
+
+// bundleCommand describes a single recorded invocation
+type bundleCommand struct {
+	Command         string  `json:"command"`
+	File            string  `json:"file"`
+	ExitCode        int     `json:"exit_code"`
+	Error           string  `json:"error,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+// runSupportBundle runs every invocation the exporter would make, once, and
+// writes the raw output together with the resulting metrics to a tarball.
+// The command outputs are named so that the extracted tarball can be passed
+// to --replay.dir.
+func runSupportBundle(logger log.Logger, runner collector.Runner, args []string) error {
+	hostname, _ := os.Hostname()
+
+	fs := flag.NewFlagSet("support-bundle", flag.ExitOnError)
+	output := fs.String("output", fmt.Sprintf("support-bundle-%s-%s.tar.gz", hostname, time.Now().Format("20060102-150405")), "Path of the tarball to write")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	recorder := collector.NewRecordingRunner(runner)
+
+	versions := map[string]string{}
+	for _, cmd := range [][]string{
+		{*ssacliPath, "version"},
+		{*smartctlPath, "--version"},
+		{*lsscsiPath, "--version"},
+	} {
+		tool := filepath.Base(cmd[0])
+		out, err := recorder.Run(context.Background(), cmd[0], cmd[1:]...)
+		if err != nil {
+			level.Warn(logger).Log("msg", "Cannot determine tool version", "tool", tool, "err", err)
+		}
+		versions[tool] = firstLine(string(out))
+	}
+
+	e := exporter.New(logger, recorder, *smartctlPath, *ssacliPath, *lsscsiPath, exporter.DefaultIntervals, *smartctlWorkers, *tombstoneLifetime)
+	e.Refresh(context.Background())
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(e); err != nil {
+		return err
+	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		level.Warn(logger).Log("msg", "Errors while gathering metrics", "err", err)
+	}
+
+	var metrics bytes.Buffer
+	for _, mf := range mfs {
+		if _, err := expfmt.MetricFamilyToText(&metrics, mf); err != nil {
+			return err
+		}
+	}
+
+	manifest := bundleManifest{
+		CreatedAt:    time.Now().UTC(),
+		Hostname:     hostname,
+		ToolVersions: versions,
+		Commands:     make([]bundleCommand, 0),
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	for _, rec := range recorder.Recordings() {
+		manifest.Commands = append(manifest.Commands, bundleCommand{
+			Command:         rec.Command,
+			File:            rec.File,
+			ExitCode:        rec.ExitCode,
+			Error:           rec.Err,
+			DurationSeconds: rec.Duration.Seconds(),
+		})
+
+		// The command could not be started, so there is no output to replay
+		if rec.ExitCode == -1 {
+			continue
+		}
+
+		if err := writeTarFile(tw, rec.File, rec.Output); err != nil {
+			return err
+		}
+		if rec.ExitCode != 0 {
+			exitFile := strings.TrimSuffix(rec.File, ".txt") + ".exit"
+			if err := writeTarFile(tw, exitFile, []byte(strconv.Itoa(rec.ExitCode)+"\n")); err != nil {
+				return err
+			}
+		}
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, "manifest.json", manifestJSON); err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, "metrics.prom", metrics.Bytes()); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	level.Info(logger).Log("msg", "Support bundle written", "output", *output, "commands", len(manifest.Commands))
+	return nil
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/john-craig/smartctl_ssacli_exporter/parser"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics about the exporter's own work, reported by CollectInstrumentation
+var (
+	commandDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "smartctl_ssacli_exporter",
+			Name:      "command_duration_seconds",
+			Help:      "Duration of ssacli, smartctl and lsscsi invocations",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{"tool", "subcommand"},
+	)
+	commandFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "smartctl_ssacli_exporter",
+			Name:      "command_failures_total",
+			Help:      "Number of failed ssacli, smartctl and lsscsi invocations, exit_code is -1 when the tool could not be run to completion",
+		},
+		[]string{"tool", "subcommand", "exit_code"},
+	)
+	parseErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "smartctl_ssacli_exporter",
+			Name:      "parse_errors_total",
+			Help:      "Number of errors encountered while parsing tool output",
+		},
+		[]string{"parser"},
+	)
+)
+
+// CollectInstrumentation sends the metrics about the exporter's own work to
+// the channel
+func CollectInstrumentation(ch chan<- prometheus.Metric) {
+	commandDuration.Collect(ch)
+	commandFailures.Collect(ch)
+	parseErrors.Collect(ch)
+}
+
+// countParseError records errors returned by the named parser function
+func countParseError(parser string, n int) {
+	parseErrors.WithLabelValues(parser).Add(float64(n))
+}
+
+// countParseErrors logs and counts the errors returned by a parser function,
+// and returns them so that the fields that failed can be skipped
+func countParseErrors(logger log.Logger, fn string, err error) parser.ParseErrors {
+	if err == nil {
+		return nil
+	}
+
+	var errs parser.ParseErrors
+	if !errors.As(err, &errs) {
+		level.Warn(logger).Log("msg", "Cannot parse tool output", "parser", fn, "err", err)
+		countParseError(fn, 1)
+		return nil
+	}
+
+	for _, e := range errs {
+		level.Warn(logger).Log("msg", "Cannot parse tool output", "parser", e.Func, "line", e.Line, "field", e.Field, "value", e.Value, "err", e.Err)
+		countParseError(e.Func, 1)
+	}
+	return errs
+}
+
+// CommandSubcommand returns a low cardinality description of the arguments
+// of an invocation: every argument identifying a controller, drive or
+// device, i.e. containing a digit, `/`, `:` or `=`, is dropped. For example
+// `ctrl slot=0 pd 1I:1:1 show detail` becomes `ctrl pd show detail`.
+func CommandSubcommand(args ...string) string {
+	kept := make([]string, 0, len(args))
+	for _, arg := range args {
+		if strings.ContainsAny(arg, "0123456789/:=") {
+			continue
+		}
+		kept = append(kept, arg)
+	}
+	return strings.Join(kept, " ")
+}
+
+// InstrumentedRunner forwards invocations to another Runner and records
+// their duration and failures.
+type InstrumentedRunner struct {
+	runner Runner
+}
+
+var _ Runner = &InstrumentedRunner{}
+
+// NewInstrumentedRunner Create new runner
+func NewInstrumentedRunner(runner Runner) *InstrumentedRunner {
+	return &InstrumentedRunner{runner: runner}
+}
+
+// Run executes the command through the wrapped runner
+func (r *InstrumentedRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	tool := filepath.Base(name)
+	subcommand := CommandSubcommand(args...)
+
+	start := time.Now()
+	out, err := r.runner.Run(ctx, name, args...)
+	commandDuration.WithLabelValues(tool, subcommand).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		commandFailures.WithLabelValues(tool, subcommand, strconv.Itoa(ExitCode(err))).Inc()
+	}
+	return out, err
+}
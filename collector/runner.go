@@ -0,0 +1,390 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Runner executes the external tools (ssacli, smartctl, lsscsi) on behalf
+// of the collectors. A single Runner is shared by every collector so that
+// the whole pipeline can be pointed at real hardware or at canned output.
+type Runner interface {
+	// Run executes the binary at name with args and returns its combined
+	// stdout and stderr. The command is aborted once ctx is done.
+	Run(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// CommandKey returns the key identifying an invocation of name with args.
+// Only the base name of the binary is used, so that the same key matches
+// regardless of where the tool is installed.
+func CommandKey(name string, args ...string) string {
+	return strings.Join(append([]string{filepath.Base(name)}, args...), " ")
+}
+
+var nonAlnumRe = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// CommandFileName returns the name of the file holding the recorded output
+// of name invoked with args, e.g. `ssacli ctrl slot=0 pd all show status`
+// is stored as `ssacli_ctrl_slot_0_pd_all_show_status.txt`.
+func CommandFileName(name string, args ...string) string {
+	return strings.Trim(nonAlnumRe.ReplaceAllString(CommandKey(name, args...), "_"), "_") + ".txt"
+}
+
+// ExitError is returned by runners that do not execute a real process when
+// the recorded command exited with a non-zero status.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return "exit status " + strconv.Itoa(e.Code)
+}
+
+// SudoRunner runs commands through sudo, except for the binaries listed as
+// unprivileged which are executed directly.
+//
+// Each command runs in its own process group and is given at most timeout to
+// complete. When it is aborted, the group is sent SIGTERM, which sudo relays
+// to the tool, and SIGKILL if it is still running after killDelay.
+type SudoRunner struct {
+	sudoPath     string
+	unprivileged []string
+
+	timeout   time.Duration
+	killDelay time.Duration
+}
+
+var _ Runner = &SudoRunner{}
+
+// NewSudoRunner Create new runner, a timeout of 0 disables the per-command
+// timeout
+func NewSudoRunner(sudoPath string, timeout time.Duration, unprivileged ...string) *SudoRunner {
+	return &SudoRunner{
+		sudoPath:     sudoPath,
+		unprivileged: unprivileged,
+		timeout:      timeout,
+		killDelay:    5 * time.Second,
+	}
+}
+
+// Run executes the command, prefixing it with sudo when required
+func (r *SudoRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	var cmd *exec.Cmd
+	if slices.Contains(r.unprivileged, name) {
+		cmd = exec.CommandContext(ctx, name, args...)
+	} else {
+		cmd = exec.CommandContext(ctx, r.sudoPath, append([]string{name}, args...)...)
+	}
+	killProcessGroupOnCancel(cmd, r.killDelay)
+
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return out, fmt.Errorf("%s aborted: %w", CommandKey(name, args...), ctx.Err())
+	}
+	return out, err
+}
+
+// FakeCommand is the canned result of a single invocation
+type FakeCommand struct {
+	Output []byte
+	Err    error
+}
+
+// FakeRunner serves canned output keyed by argv, see CommandKey. It never
+// executes anything and is safe for concurrent use.
+type FakeRunner struct {
+	mu       sync.Mutex
+	commands map[string]FakeCommand
+	calls    []string
+}
+
+var _ Runner = &FakeRunner{}
+
+// NewFakeRunner Create new runner
+func NewFakeRunner() *FakeRunner {
+	return &FakeRunner{
+		commands: make(map[string]FakeCommand),
+		calls:    make([]string, 0),
+	}
+}
+
+// Set registers the output and error returned when name is run with args
+func (r *FakeRunner) Set(output []byte, err error, name string, args ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.commands[CommandKey(name, args...)] = FakeCommand{Output: output, Err: err}
+}
+
+// Run returns the canned result for the invocation, or an error if none was
+// registered
+func (r *FakeRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	key := CommandKey(name, args...)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, key)
+	cmd, ok := r.commands[key]
+	if !ok {
+		return nil, fmt.Errorf("no canned output for %q", key)
+	}
+	return cmd.Output, cmd.Err
+}
+
+// Calls returns the keys of every invocation made so far, in order
+func (r *FakeRunner) Calls() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.calls)
+}
+
+// ReplayRunner serves the output of commands recorded into a directory, one
+// file per invocation named after CommandFileName. An optional sibling file
+// with the `.exit` extension holds the recorded exit code. Files are read on
+// every call, so the directory can be edited while the exporter is running.
+type ReplayRunner struct {
+	dir string
+}
+
+var _ Runner = &ReplayRunner{}
+
+// NewReplayRunner Create new runner
+func NewReplayRunner(dir string) (*ReplayRunner, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return &ReplayRunner{dir: dir}, nil
+}
+
+// Run returns the recorded output of the invocation
+func (r *ReplayRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	file := filepath.Join(r.dir, CommandFileName(name, args...))
+
+	out, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("no recorded output for %q: %w", CommandKey(name, args...), err)
+	}
+
+	code, err := os.ReadFile(strings.TrimSuffix(file, ".txt") + ".exit")
+	if errors.Is(err, fs.ErrNotExist) {
+		return out, nil
+	}
+	if err != nil {
+		return out, err
+	}
+
+	exitCode, err := strconv.Atoi(strings.TrimSpace(string(code)))
+	if err != nil {
+		return out, fmt.Errorf("invalid exit code recorded for %q: %w", CommandKey(name, args...), err)
+	}
+	if exitCode != 0 {
+		return out, &ExitError{Code: exitCode}
+	}
+	return out, nil
+}
+
+// ExitCode returns the exit code carried by an error returned from a Runner:
+// 0 for nil, the process exit status when known, and -1 otherwise (e.g. the
+// binary could not be started).
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	var replayErr *ExitError
+	if errors.As(err, &replayErr) {
+		return replayErr.Code
+	}
+	return -1
+}
+
+// Recording is a single invocation captured by a RecordingRunner
+type Recording struct {
+	Command  string
+	File     string
+	Output   []byte
+	ExitCode int
+	Err      string
+	Duration time.Duration
+}
+
+// RecordingRunner forwards every invocation to another Runner and keeps its
+// raw output, exit code and duration. When a command is run several times
+// only the latest result is kept.
+type RecordingRunner struct {
+	runner Runner
+
+	mu         sync.Mutex
+	order      []string
+	recordings map[string]Recording
+}
+
+var _ Runner = &RecordingRunner{}
+
+// NewRecordingRunner Create new runner
+func NewRecordingRunner(runner Runner) *RecordingRunner {
+	return &RecordingRunner{
+		runner:     runner,
+		order:      make([]string, 0),
+		recordings: make(map[string]Recording),
+	}
+}
+
+// Run executes the command through the wrapped runner and records the result
+func (r *RecordingRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	start := time.Now()
+	out, err := r.runner.Run(ctx, name, args...)
+
+	rec := Recording{
+		Command:  CommandKey(name, args...),
+		File:     CommandFileName(name, args...),
+		Output:   out,
+		ExitCode: ExitCode(err),
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		rec.Err = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.recordings[rec.Command]; !ok {
+		r.order = append(r.order, rec.Command)
+	}
+	r.recordings[rec.Command] = rec
+
+	return out, err
+}
+
+// Recordings returns every recorded invocation in the order it was first run
+func (r *RecordingRunner) Recordings() []Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recs := make([]Recording, 0, len(r.order))
+	for _, command := range r.order {
+		recs = append(recs, r.recordings[command])
+	}
+	return recs
+}
+
+// SerialRunner forwards invocations to another Runner, queueing those of the
+// serialized binaries so that only one of them runs at any time. ssacli in
+// particular fails when another instance holds the controller lock. A
+// serialized invocation that fails on the lock, see lockContention, is
+// retried up to retries times, waiting backoff before the first retry and
+// twice as long before each next one. Other failures are not retried.
+type SerialRunner struct {
+	runner     Runner
+	serialized []string
+
+	retries int
+	backoff time.Duration
+
+	queue chan struct{}
+}
+
+var _ Runner = &SerialRunner{}
+
+// NewSerialRunner Create new runner
+func NewSerialRunner(runner Runner, retries int, backoff time.Duration, serialized ...string) *SerialRunner {
+	return &SerialRunner{
+		runner:     runner,
+		serialized: serialized,
+		retries:    retries,
+		backoff:    backoff,
+		queue:      make(chan struct{}, 1),
+	}
+}
+
+// Run executes the command, waiting for its turn if it is serialized
+func (r *SerialRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if !slices.Contains(r.serialized, name) {
+		return r.runner.Run(ctx, name, args...)
+	}
+
+	backoff := r.backoff
+	for attempt := 0; ; attempt++ {
+		out, err := r.runSerialized(ctx, name, args...)
+		if err == nil || attempt >= r.retries || ctx.Err() != nil || !lockContention(out) {
+			return out, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return out, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// lockMessages are the messages of ssacli telling that another instance
+// holds the controller lock, e.g. `Error: Another instance of ACU is already
+// running (possibly a service).`
+var lockMessages = [][]byte{
+	[]byte("another instance"),
+	[]byte("already running"),
+	[]byte("is locked"),
+}
+
+// lockContention tells whether the output of a failed invocation reports
+// that the controller lock is held, a failure that goes away on its own
+func lockContention(out []byte) bool {
+	out = bytes.ToLower(out)
+	for _, message := range lockMessages {
+		if bytes.Contains(out, message) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *SerialRunner) runSerialized(ctx context.Context, name string, args ...string) ([]byte, error) {
+	select {
+	case r.queue <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-r.queue }()
+
+	return r.runner.Run(ctx, name, args...)
+}
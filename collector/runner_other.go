@@ -0,0 +1,14 @@
+//go:build !unix
+
+package collector
+
+import (
+	"os/exec"
+	"time"
+)
+
+// killProcessGroupOnCancel only kills the process itself on platforms
+// without process groups.
+func killProcessGroupOnCancel(cmd *exec.Cmd, killDelay time.Duration) {
+	cmd.WaitDelay = killDelay
+}
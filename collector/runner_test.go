@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSerialRunnerRetries(t *testing.T) {
+	const locked = "Error: Another instance of ACU is already running (possibly a service)."
+
+	tests := []struct {
+		name    string
+		out     string
+		backoff time.Duration
+		calls   int
+	}{
+		{name: "lock held", out: locked, backoff: time.Millisecond, calls: 4},
+		{name: "other failure", out: "Error: The controller identified by \"slot=1\" was not detected.", backoff: time.Millisecond, calls: 1},
+		// The context expires while waiting for the first retry
+		{name: "deadline", out: locked, backoff: time.Hour, calls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := NewFakeRunner()
+			fake.Set([]byte(tt.out), errors.New("exit status 1"), "ssacli", "ctrl", "all", "show", "config", "detail")
+			runner := NewSerialRunner(fake, 3, tt.backoff, "ssacli")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			if _, err := runner.Run(ctx, "ssacli", "ctrl", "all", "show", "config", "detail"); err == nil {
+				t.Fatalf("Run() error = nil, want an error")
+			}
+			if calls := len(fake.Calls()); calls != tt.calls {
+				t.Errorf("ssacli run %d times, want %d", calls, tt.calls)
+			}
+		})
+	}
+}
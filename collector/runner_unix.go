@@ -0,0 +1,24 @@
+//go:build unix
+
+package collector
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// killProcessGroupOnCancel starts cmd in its own process group and makes its
+// cancellation terminate the whole group, so that no child of sudo or of the
+// tool itself is left behind.
+func killProcessGroupOnCancel(cmd *exec.Cmd, killDelay time.Duration) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		pgid := cmd.Process.Pid
+		time.AfterFunc(killDelay, func() {
+			syscall.Kill(-pgid, syscall.SIGKILL)
+		})
+		return syscall.Kill(-pgid, syscall.SIGTERM)
+	}
+	cmd.WaitDelay = killDelay + time.Second
+}
@@ -58,6 +58,11 @@ type SMARTDevice struct {
 
 	scsi_controller_slot string
 	scsi_disk_index      string
+
+	// The ssacli physical drive the disk was matched with, empty when it
+	// could not be matched
+	ssacli_disk_id string
+	ssacli_bay     string
 }
 
 // SMARTctl object
@@ -73,6 +78,8 @@ func NewSMARTctl(logger log.Logger,
 	json gjson.Result,
 	conID string,
 	diskN int,
+	diskID string,
+	bay string,
 	ch chan<- prometheus.Metric) *SMARTctl {
 	var model_name string
 	if obj := json.Get("model_name"); obj.Exists() {
@@ -93,6 +100,8 @@ func NewSMARTctl(logger log.Logger,
 			device:               strings.TrimPrefix(strings.TrimSpace(json.Get("device.name").String()), "/dev/"),
 			scsi_controller_slot: strings.TrimSpace(conID),
 			scsi_disk_index:      strconv.Itoa(diskN),
+			ssacli_disk_id:       diskID,
+			ssacli_bay:           bay,
 			serial:               strings.TrimSpace(json.Get("serial_number").String()),
 			family:               strings.TrimSpace(GetStringIfExists(json, "model_family", "unknown")),
 			model:                strings.TrimSpace(model_name),
@@ -121,6 +130,7 @@ func (smart *SMARTctl) Collect() {
 	smart.mineDeviceSelfTestLog()
 	smart.mineDeviceERC()
 	smart.mineSmartStatus()
+	smart.mineEnduranceUsed() // ATA/SATA, NVME, SCSI, SAS
 
 	if smart.device.interface_ == "nvme" {
 		smart.mineNvmePercentageUsed()
@@ -149,6 +159,8 @@ func (smart *SMARTctl) mineExitStatus() {
 		smart.device.device,
 		smart.device.scsi_controller_slot,
 		smart.device.scsi_disk_index,
+		smart.device.ssacli_disk_id,
+		smart.device.ssacli_bay,
 	)
 }
 
@@ -160,6 +172,8 @@ func (smart *SMARTctl) mineDevice() {
 		smart.device.device,
 		smart.device.scsi_controller_slot,
 		smart.device.scsi_disk_index,
+		smart.device.ssacli_disk_id,
+		smart.device.ssacli_bay,
 		smart.device.interface_,
 		smart.device.protocol,
 		smart.device.family,
@@ -189,6 +203,8 @@ func (smart *SMARTctl) mineCapacity() {
 		smart.device.device,
 		smart.device.scsi_controller_slot,
 		smart.device.scsi_disk_index,
+		smart.device.ssacli_disk_id,
+		smart.device.ssacli_bay,
 	)
 	smart.ch <- prometheus.MustNewConstMetric(
 		metricDeviceCapacityBytes,
@@ -197,6 +213,8 @@ func (smart *SMARTctl) mineCapacity() {
 		smart.device.device,
 		smart.device.scsi_controller_slot,
 		smart.device.scsi_disk_index,
+		smart.device.ssacli_disk_id,
+		smart.device.ssacli_bay,
 	)
 	nvme_total_capacity := smart.json.Get("nvme_total_capacity")
 	if nvme_total_capacity.Exists() {
@@ -207,6 +225,8 @@ func (smart *SMARTctl) mineCapacity() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 		)
 	}
 }
@@ -220,6 +240,8 @@ func (smart *SMARTctl) mineBlockSize() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 			blockType,
 		)
 	}
@@ -239,6 +261,8 @@ func (smart *SMARTctl) mineInterfaceSpeed() {
 					smart.device.device,
 					smart.device.scsi_controller_slot,
 					smart.device.scsi_disk_index,
+					smart.device.ssacli_disk_id,
+					smart.device.ssacli_bay,
 					speedType,
 				)
 			}
@@ -272,6 +296,8 @@ func (smart *SMARTctl) mineDeviceAttribute() {
 				smart.device.device,
 				smart.device.scsi_controller_slot,
 				smart.device.scsi_disk_index,
+				smart.device.ssacli_disk_id,
+				smart.device.ssacli_bay,
 				name,
 				flagsShort,
 				flagsLong,
@@ -293,6 +319,8 @@ func (smart *SMARTctl) minePowerOnSeconds() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 		)
 	}
 }
@@ -309,6 +337,8 @@ func (smart *SMARTctl) mineRotationRate() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 		)
 	}
 }
@@ -325,6 +355,8 @@ func (smart *SMARTctl) mineTemperatures() {
 				smart.device.device,
 				smart.device.scsi_controller_slot,
 				smart.device.scsi_disk_index,
+				smart.device.ssacli_disk_id,
+				smart.device.ssacli_bay,
 				key.String(),
 			)
 			return true
@@ -343,6 +375,8 @@ func (smart *SMARTctl) minePowerCycleCount() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 		)
 		return
 	}
@@ -357,6 +391,8 @@ func (smart *SMARTctl) minePowerCycleCount() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 		)
 		return
 	}
@@ -372,10 +408,69 @@ func (smart *SMARTctl) mineDeviceSCTStatus() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 		)
 	}
 }
 
+// ataWearAttributes are the ATA attributes whose normalized value is the
+// percentage of endurance remaining, in order of preference
+var ataWearAttributes = []int64{
+	233, // Media_Wearout_Indicator
+	231, // SSD_Life_Left
+	202, // Percent_Lifetime_Remain
+	169, // Remaining_Lifetime_Perc
+	177, // Wear_Leveling_Count
+}
+
+// EnduranceUsed returns the percentage of the rated endurance of an SSD that
+// was used, from whichever indicator the disk reports: the NVMe health log,
+// the SCSI endurance indicator, the ATA device statistics or the ATA wear
+// attributes. ok is false when the disk reports none of them.
+func EnduranceUsed(json gjson.Result) (float64, bool) {
+	if used := json.Get("nvme_smart_health_information_log.percentage_used"); used.Exists() {
+		return used.Float(), true
+	}
+	if used := json.Get("scsi_percentage_used_endurance_indicator"); used.Exists() {
+		return used.Float(), true
+	}
+	for _, page := range json.Get("ata_device_statistics.pages").Array() {
+		for _, statistic := range page.Get("table").Array() {
+			if statistic.Get("name").String() == "Percentage Used Endurance Indicator" && statistic.Get("flags.valid").Bool() {
+				return statistic.Get("value").Float(), true
+			}
+		}
+	}
+
+	attributes := json.Get("ata_smart_attributes.table").Array()
+	for _, id := range ataWearAttributes {
+		for _, attribute := range attributes {
+			if attribute.Get("id").Int() == id {
+				return 100 - attribute.Get("value").Float(), true
+			}
+		}
+	}
+	return 0, false
+}
+
+func (smart *SMARTctl) mineEnduranceUsed() {
+	used, ok := EnduranceUsed(smart.json)
+	if !ok {
+		return
+	}
+	smart.ch <- prometheus.MustNewConstMetric(
+		metricDeviceEnduranceUsed,
+		prometheus.GaugeValue,
+		used,
+		smart.device.device,
+		smart.device.scsi_controller_slot,
+		smart.device.scsi_disk_index,
+		smart.device.ssacli_disk_id,
+		smart.device.ssacli_bay,
+	)
+}
+
 func (smart *SMARTctl) mineNvmePercentageUsed() {
 	smart.ch <- prometheus.MustNewConstMetric(
 		metricDevicePercentageUsed,
@@ -384,6 +479,8 @@ func (smart *SMARTctl) mineNvmePercentageUsed() {
 		smart.device.device,
 		smart.device.scsi_controller_slot,
 		smart.device.scsi_disk_index,
+		smart.device.ssacli_disk_id,
+		smart.device.ssacli_bay,
 	)
 }
 
@@ -395,6 +492,8 @@ func (smart *SMARTctl) mineNvmeAvailableSpare() {
 		smart.device.device,
 		smart.device.scsi_controller_slot,
 		smart.device.scsi_disk_index,
+		smart.device.ssacli_disk_id,
+		smart.device.ssacli_bay,
 	)
 }
 
@@ -406,6 +505,8 @@ func (smart *SMARTctl) mineNvmeAvailableSpareThreshold() {
 		smart.device.device,
 		smart.device.scsi_controller_slot,
 		smart.device.scsi_disk_index,
+		smart.device.ssacli_disk_id,
+		smart.device.ssacli_bay,
 	)
 }
 
@@ -417,6 +518,8 @@ func (smart *SMARTctl) mineNvmeCriticalWarning() {
 		smart.device.device,
 		smart.device.scsi_controller_slot,
 		smart.device.scsi_disk_index,
+		smart.device.ssacli_disk_id,
+		smart.device.ssacli_bay,
 	)
 }
 
@@ -428,6 +531,8 @@ func (smart *SMARTctl) mineNvmeMediaErrors() {
 		smart.device.device,
 		smart.device.scsi_controller_slot,
 		smart.device.scsi_disk_index,
+		smart.device.ssacli_disk_id,
+		smart.device.ssacli_bay,
 	)
 }
 
@@ -439,6 +544,8 @@ func (smart *SMARTctl) mineNvmeNumErrLogEntries() {
 		smart.device.device,
 		smart.device.scsi_controller_slot,
 		smart.device.scsi_disk_index,
+		smart.device.ssacli_disk_id,
+		smart.device.ssacli_bay,
 	)
 }
 
@@ -480,6 +587,8 @@ func (smart *SMARTctl) mineNvmeBytesRead() {
 		smart.device.device,
 		smart.device.scsi_controller_slot,
 		smart.device.scsi_disk_index,
+		smart.device.ssacli_disk_id,
+		smart.device.ssacli_bay,
 	)
 }
 
@@ -498,6 +607,8 @@ func (smart *SMARTctl) mineNvmeBytesWritten() {
 		smart.device.device,
 		smart.device.scsi_controller_slot,
 		smart.device.scsi_disk_index,
+		smart.device.ssacli_disk_id,
+		smart.device.ssacli_bay,
 	)
 }
 
@@ -514,6 +625,8 @@ func (smart *SMARTctl) mineSCSIBytesRead() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 		)
 	}
 }
@@ -531,6 +644,8 @@ func (smart *SMARTctl) mineSCSIBytesWritten() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 		)
 	}
 }
@@ -543,6 +658,8 @@ func (smart *SMARTctl) mineSmartStatus() {
 		smart.device.device,
 		smart.device.scsi_controller_slot,
 		smart.device.scsi_disk_index,
+		smart.device.ssacli_disk_id,
+		smart.device.ssacli_bay,
 	)
 }
 
@@ -562,6 +679,8 @@ func (smart *SMARTctl) mineDeviceStatistics() {
 				smart.device.device,
 				smart.device.scsi_controller_slot,
 				smart.device.scsi_disk_index,
+				smart.device.ssacli_disk_id,
+				smart.device.ssacli_bay,
 				table,
 				strings.TrimSpace(statistic.Get("name").String()),
 				strings.TrimSpace(statistic.Get("flags.string").String()),
@@ -583,6 +702,8 @@ func (smart *SMARTctl) mineDeviceStatistics() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 			"SATA PHY Event Counters",
 			strings.TrimSpace(statistic.Get("name").String()),
 			"V---",
@@ -611,6 +732,8 @@ func (smart *SMARTctl) mineDeviceErrorLog() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 			logType,
 		)
 	}
@@ -625,6 +748,8 @@ func (smart *SMARTctl) mineDeviceSelfTestLog() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 			logType,
 		)
 		smart.ch <- prometheus.MustNewConstMetric(
@@ -634,6 +759,8 @@ func (smart *SMARTctl) mineDeviceSelfTestLog() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 			logType,
 		)
 	}
@@ -648,6 +775,8 @@ func (smart *SMARTctl) mineDeviceERC() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 			ercType,
 		)
 	}
@@ -663,6 +792,8 @@ func (smart *SMARTctl) mineSCSIGrownDefectList() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 		)
 	}
 }
@@ -677,6 +808,8 @@ func (smart *SMARTctl) mineSCSIErrorCounterLog() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 		)
 		smart.ch <- prometheus.MustNewConstMetric(
 			metricReadErrorsCorrectedByEccFast,
@@ -685,6 +818,8 @@ func (smart *SMARTctl) mineSCSIErrorCounterLog() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 		)
 		smart.ch <- prometheus.MustNewConstMetric(
 			metricReadErrorsCorrectedByEccDelayed,
@@ -693,6 +828,8 @@ func (smart *SMARTctl) mineSCSIErrorCounterLog() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 		)
 		smart.ch <- prometheus.MustNewConstMetric(
 			metricReadTotalUncorrectedErrors,
@@ -701,6 +838,8 @@ func (smart *SMARTctl) mineSCSIErrorCounterLog() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 		)
 		smart.ch <- prometheus.MustNewConstMetric(
 			metricWriteErrorsCorrectedByRereadsRewrites,
@@ -709,6 +848,8 @@ func (smart *SMARTctl) mineSCSIErrorCounterLog() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 		)
 		smart.ch <- prometheus.MustNewConstMetric(
 			metricWriteErrorsCorrectedByEccFast,
@@ -717,6 +858,8 @@ func (smart *SMARTctl) mineSCSIErrorCounterLog() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 		)
 		smart.ch <- prometheus.MustNewConstMetric(
 			metricWriteErrorsCorrectedByEccDelayed,
@@ -725,6 +868,8 @@ func (smart *SMARTctl) mineSCSIErrorCounterLog() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 		)
 		smart.ch <- prometheus.MustNewConstMetric(
 			metricWriteTotalUncorrectedErrors,
@@ -733,6 +878,8 @@ func (smart *SMARTctl) mineSCSIErrorCounterLog() {
 			smart.device.device,
 			smart.device.scsi_controller_slot,
 			smart.device.scsi_disk_index,
+			smart.device.ssacli_disk_id,
+			smart.device.ssacli_bay,
 		)
 		// TODO: Should we also export the verify category?
 	}
@@ -757,6 +904,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 			"interface",
 			"protocol",
 			"model_family",
@@ -788,6 +937,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -798,6 +949,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -808,6 +961,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -818,6 +973,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 			"blocks_type",
 		},
 		nil,
@@ -829,6 +986,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 			"speed_type",
 		},
 		nil,
@@ -840,6 +999,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 			"attribute_name",
 			"attribute_flags_short",
 			"attribute_flags_long",
@@ -855,6 +1016,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -865,6 +1028,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -875,6 +1040,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 			"temperature_type",
 		},
 		nil,
@@ -886,6 +1053,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -896,6 +1065,20 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
+		},
+		nil,
+	)
+	metricDeviceEnduranceUsed = prometheus.NewDesc(
+		"smartctl_device_endurance_used_percent",
+		"Percentage of the rated endurance of an SSD that was used, normalized across NVMe, SCSI and ATA indicators",
+		[]string{
+			"device",
+			"scsi_controller_slot",
+			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -906,6 +1089,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -916,6 +1101,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -926,6 +1113,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -936,6 +1125,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -946,6 +1137,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -956,6 +1149,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -966,6 +1161,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -976,6 +1173,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -986,6 +1185,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -996,6 +1197,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -1006,6 +1209,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 			"statistic_table",
 			"statistic_name",
 			"statistic_flags_short",
@@ -1020,6 +1225,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 			"error_log_type",
 		},
 		nil,
@@ -1031,6 +1238,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 			"self_test_log_type",
 		},
 		nil,
@@ -1042,6 +1251,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 			"self_test_log_type",
 		},
 		nil,
@@ -1053,6 +1264,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 			"op_type",
 		},
 		nil,
@@ -1064,6 +1277,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -1074,6 +1289,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -1084,6 +1301,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -1094,6 +1313,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -1104,6 +1325,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -1114,6 +1337,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -1124,6 +1349,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -1134,6 +1361,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
@@ -1144,6 +1373,8 @@ var (
 			"device",
 			"scsi_controller_slot",
 			"scsi_disk_index",
+			"ssacli_disk_id",
+			"ssacli_bay",
 		},
 		nil,
 	)
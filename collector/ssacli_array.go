@@ -0,0 +1,261 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/john-craig/smartctl_ssacli_exporter/parser"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ prometheus.Collector = &SsacliArrayCollector{}
+
+// SsacliArrayCollector Contain the arrays of a controller
+type SsacliArrayCollector struct {
+	logger log.Logger
+	runner Runner
+
+	ssacliPath string
+
+	ConID string
+
+	mu          sync.Mutex
+	cachedData  *parser.SsacliArray
+	topology    []parser.SsacliConfigArray
+	physCols    []*SsacliPhysDiskCollector
+	lastCollect time.Time
+
+	status      *prometheus.Desc
+	info        *prometheus.Desc
+	unusedSpace *prometheus.Desc
+	usedSpace   *prometheus.Desc
+	members     *prometheus.Desc
+	spares      *prometheus.Desc
+	spareUsable *prometheus.Desc
+	usable      *prometheus.Desc
+}
+
+// NewSsacliArrayCollector Create new collector
+func NewSsacliArrayCollector(logger log.Logger, runner Runner, conID, ssacliPath string) *SsacliArrayCollector {
+	var (
+		namespace = "ssacli"
+		subsystem = "array"
+		labels    = []string{"conID", "arrayID"}
+	)
+
+	return &SsacliArrayCollector{
+		logger: logger,
+		runner: runner,
+
+		ssacliPath: ssacliPath,
+
+		ConID: conID,
+
+		cachedData:  nil,
+		lastCollect: time.Time{},
+
+		status: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "status"),
+			"Array status, 1 for the current state and 0 for the others",
+			[]string{"conID", "arrayID", "state"},
+			nil,
+		),
+		info: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "info"),
+			"Array type, interface type and spare type, always 1",
+			[]string{"conID", "arrayID", "arrayType", "interfaceType", "spareType"},
+			nil,
+		),
+		unusedSpace: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "unused_space_bytes"),
+			"Array space not allocated to any logical drive",
+			labels,
+			nil,
+		),
+		usedSpace: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "used_space_bytes"),
+			"Array space allocated to logical drives",
+			labels,
+			nil,
+		),
+		members: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "member_drives"),
+			"Number of physical drives holding data of the array",
+			labels,
+			nil,
+		),
+		spares: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "spare_drives"),
+			"Number of spare drives assigned to the array",
+			labels,
+			nil,
+		),
+		spareUsable: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "spare_drive_usable"),
+			"Whether a spare drive assigned to the array could replace any of its data drives: it is OK, of the same interface type and at least as large as the largest of them",
+			[]string{"conID", "arrayID", "diskID"},
+			nil,
+		),
+		usable: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "usable_spare"),
+			"Whether at least one usable spare drive is assigned to the array",
+			labels,
+			nil,
+		),
+	}
+}
+
+// usableSpare reports whether the spare drive, whose current status is
+// status, could replace any of the data drives of the array: it must be OK,
+// of the same interface type and at least as large as the largest of them.
+// A spare of an array without data drives is not usable.
+func usableSpare(spare parser.SsacliConfigPhysDisk, status string, members []parser.SsacliConfigPhysDisk) bool {
+	if len(members) == 0 {
+		return false
+	}
+	if parser.SsacliState(status) != "OK" || spare.ParseErrs.Failed("Size") {
+		return false
+	}
+	for _, member := range members {
+		if member.SsacliPhysDiskData.IntType != spare.SsacliPhysDiskData.IntType {
+			return false
+		}
+		if member.ParseErrs.Failed("Size") || member.SsacliPhysDiskData.SizeBytes > spare.SsacliPhysDiskData.SizeBytes {
+			return false
+		}
+	}
+	return true
+}
+
+// LastRefresh returns when the cached data was last refreshed, or the zero
+// time if it never was
+func (c *SsacliArrayCollector) LastRefresh() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lastCollect
+}
+
+// Describe return all description to chanel
+func (c *SsacliArrayCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+// Refresh runs ssacli and replaces the cached data. The error is that of
+// the invocation, the data is kept when it fails.
+func (c *SsacliArrayCollector) Refresh(ctx context.Context) error {
+	level.Info(c.logger).Log("msg", "SsacliArrayCollector: Invoking ssacli binary", "ssacliPath", c.ssacliPath)
+	out, err := c.runner.Run(ctx, c.ssacliPath, "ctrl", "slot="+c.ConID, "array", "all", "show", "detail")
+	level.Debug(c.logger).Log("msg", "SsacliArrayCollector: ssacli ctrl slot=N array all show detail", "conId", c.ConID, "out", out)
+
+	if err != nil {
+		level.Error(c.logger).Log("msg", "Failed to execute shell command", "conId", c.ConID, "out", out, "err", err)
+		return err
+	}
+
+	data, err := parser.ParseSsacliArray(string(out))
+	countParseErrors(c.logger, "parseSsacliArray", err)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cachedData = data
+	c.lastCollect = time.Now()
+	return nil
+}
+
+// Clear drops the data of the last refresh, for controllers that no longer
+// have any array
+func (c *SsacliArrayCollector) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cachedData = nil
+	c.lastCollect = time.Time{}
+}
+
+// SetTopology sets the arrays of the controller configuration, which tell
+// the member and spare drives of each array
+func (c *SsacliArrayCollector) SetTopology(arrays []parser.SsacliConfigArray) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.topology = arrays
+}
+
+// SetPhysDiskCollectors sets the collectors of the physical drives of the
+// controller, which hold the current status of the spare drives
+func (c *SsacliArrayCollector) SetPhysDiskCollectors(physCols []*SsacliPhysDiskCollector) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.physCols = physCols
+}
+
+// physDiskStatus returns the current status of a physical drive, the status
+// of the configuration when its collector is unknown
+func physDiskStatus(physCols []*SsacliPhysDiskCollector, physDisk parser.SsacliConfigPhysDisk) string {
+	for _, physCol := range physCols {
+		if physCol.DiskID == physDisk.ID {
+			return physCol.Status()
+		}
+	}
+	return physDisk.SsacliPhysDiskData.Status
+}
+
+// Collect sends the metrics of the last refresh to the channel
+func (c *SsacliArrayCollector) Collect(ch chan<- prometheus.Metric) {
+	level.Debug(c.logger).Log("msg", "SsacliArrayCollector: Collect function called")
+
+	c.mu.Lock()
+	data := c.cachedData
+	topology := c.topology
+	physCols := c.physCols
+	c.mu.Unlock()
+
+	if data == nil {
+		return
+	}
+
+	for _, array := range data.SsacliArrayData {
+		collectStateSet(ch, c.status, ArrayStates, array.Status, c.ConID, array.ID)
+		ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1, c.ConID, array.ID, array.ArrayType, array.InterfaceType, array.SpareType)
+
+		if !array.ParseErrs.Failed("Unused Space") {
+			ch <- prometheus.MustNewConstMetric(c.unusedSpace, prometheus.GaugeValue, array.UnusedSpace, c.ConID, array.ID)
+		}
+		if !array.ParseErrs.Failed("Used Space") {
+			ch <- prometheus.MustNewConstMetric(c.usedSpace, prometheus.GaugeValue, array.UsedSpace, c.ConID, array.ID)
+		}
+
+		for _, configArray := range topology {
+			if configArray.ID != array.ID {
+				continue
+			}
+
+			members := make([]parser.SsacliConfigPhysDisk, 0)
+			spares := make([]parser.SsacliConfigPhysDisk, 0)
+			for _, physDisk := range configArray.PhysDisks {
+				if strings.Contains(physDisk.SsacliPhysDiskData.DriveType, "Spare") {
+					spares = append(spares, physDisk)
+				} else {
+					members = append(members, physDisk)
+				}
+			}
+			ch <- prometheus.MustNewConstMetric(c.members, prometheus.GaugeValue, float64(len(members)), c.ConID, array.ID)
+			ch <- prometheus.MustNewConstMetric(c.spares, prometheus.GaugeValue, float64(len(spares)), c.ConID, array.ID)
+
+			usable := false
+			for _, spare := range spares {
+				ok := usableSpare(spare, physDiskStatus(physCols, spare), members)
+				usable = usable || ok
+				ch <- prometheus.MustNewConstMetric(c.spareUsable, prometheus.GaugeValue, boolToFloat(ok), c.ConID, array.ID, spare.ID)
+			}
+			ch <- prometheus.MustNewConstMetric(c.usable, prometheus.GaugeValue, boolToFloat(usable), c.ConID, array.ID)
+		}
+	}
+}
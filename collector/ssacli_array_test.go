@@ -0,0 +1,52 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/john-craig/smartctl_ssacli_exporter/parser"
+)
+
+func configPhysDisk(id, intType string, size float64) parser.SsacliConfigPhysDisk {
+	return parser.SsacliConfigPhysDisk{
+		ID: id,
+		SsacliPhysDiskData: parser.SsacliPhysDiskData{
+			Status:    "OK",
+			IntType:   intType,
+			SizeBytes: size,
+		},
+	}
+}
+
+func TestUsableSpare(t *testing.T) {
+	members := []parser.SsacliConfigPhysDisk{
+		configPhysDisk("1I:1:1", "SAS", 600e9),
+		configPhysDisk("1I:1:2", "SAS", 900e9),
+	}
+	unparsed := configPhysDisk("1I:1:4", "SAS", 0)
+	unparsed.ParseErrs = parser.ParseErrors{{Field: "Size"}}
+
+	tests := []struct {
+		name    string
+		spare   parser.SsacliConfigPhysDisk
+		status  string
+		members []parser.SsacliConfigPhysDisk
+		want    bool
+	}{
+		{name: "as large as the largest member", spare: configPhysDisk("1I:1:4", "SAS", 900e9), status: "OK", members: members, want: true},
+		{name: "larger", spare: configPhysDisk("1I:1:4", "SAS", 1.2e12), status: "OK", members: members, want: true},
+		{name: "smaller than the largest member", spare: configPhysDisk("1I:1:4", "SAS", 600e9), status: "OK", members: members, want: false},
+		{name: "other interface type", spare: configPhysDisk("1I:1:4", "Solid State SAS", 1.2e12), status: "OK", members: members, want: false},
+		{name: "failed", spare: configPhysDisk("1I:1:4", "SAS", 900e9), status: "Failed", members: members, want: false},
+		{name: "predictive failure", spare: configPhysDisk("1I:1:4", "SAS", 900e9), status: "Predictive Failure", members: members, want: false},
+		{name: "size not parsed", spare: unparsed, status: "OK", members: members, want: false},
+		{name: "no members", spare: configPhysDisk("1I:1:4", "SAS", 900e9), status: "OK", members: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := usableSpare(tt.spare, tt.status, tt.members); got != tt.want {
+				t.Errorf("usableSpare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/john-craig/smartctl_ssacli_exporter/parser"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ prometheus.Collector = &SsacliEnclosureCollector{}
+
+// SsacliEnclosureCollector Contain the enclosures of a controller
+type SsacliEnclosureCollector struct {
+	logger log.Logger
+	runner Runner
+
+	ssacliPath string
+
+	ConID string
+
+	mu          sync.Mutex
+	cachedData  *parser.SsacliEnclosure
+	physDisks   []parser.SsacliConfigPhysDisk
+	lastCollect time.Time
+
+	status        *prometheus.Desc
+	info          *prometheus.Desc
+	fanStatus     *prometheus.Desc
+	tempStatus    *prometheus.Desc
+	powerStatus   *prometheus.Desc
+	driveBays     *prometheus.Desc
+	occupiedBays  *prometheus.Desc
+	emptyBays     *prometheus.Desc
+	expanderInfo  *prometheus.Desc
+	sensorTemp    *prometheus.Desc
+	sensorMaxTemp *prometheus.Desc
+}
+
+// NewSsacliEnclosureCollector Create new collector
+func NewSsacliEnclosureCollector(logger log.Logger, runner Runner, conID, ssacliPath string) *SsacliEnclosureCollector {
+	var (
+		namespace = "ssacli"
+		subsystem = "enclosure"
+		labels    = []string{"conID", "enclosureID"}
+	)
+
+	return &SsacliEnclosureCollector{
+		logger: logger,
+		runner: runner,
+
+		ssacliPath: ssacliPath,
+
+		ConID: conID,
+
+		cachedData:  nil,
+		lastCollect: time.Time{},
+
+		status: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "status"),
+			"Enclosure status, 1 for the current state and 0 for the others",
+			[]string{"conID", "enclosureID", "state"},
+			nil,
+		),
+		info: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "info"),
+			"Enclosure name, location, vendor, serial number and firmware version, always 1",
+			[]string{"conID", "enclosureID", "name", "location", "vendorID", "SN", "firmwareVersion"},
+			nil,
+		),
+		fanStatus: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "fan_status"),
+			"Enclosure fan status, 1 for the current state and 0 for the others",
+			[]string{"conID", "enclosureID", "state"},
+			nil,
+		),
+		tempStatus: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "temperature_status"),
+			"Enclosure temperature status, 1 for the current state and 0 for the others",
+			[]string{"conID", "enclosureID", "state"},
+			nil,
+		),
+		powerStatus: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "power_supply_status"),
+			"Enclosure power supply status, 1 for the current state and 0 for the others",
+			[]string{"conID", "enclosureID", "state"},
+			nil,
+		),
+		driveBays: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "drive_bays"),
+			"Number of drive bays of the enclosure",
+			labels,
+			nil,
+		),
+		occupiedBays: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "occupied_bays"),
+			"Number of drive bays of the enclosure holding a physical drive",
+			labels,
+			nil,
+		),
+		emptyBays: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "empty_bays"),
+			"Number of drive bays of the enclosure holding no physical drive",
+			labels,
+			nil,
+		),
+		expanderInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "expander_info"),
+			"SAS expander of the enclosure and its firmware version, always 1",
+			[]string{"conID", "enclosureID", "expanderID", "firmwareVersion"},
+			nil,
+		),
+		sensorTemp: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "sensor_temperature_celsius"),
+			"Current temperature of an enclosure sensor",
+			[]string{"conID", "enclosureID", "sensorID", "location"},
+			nil,
+		),
+		sensorMaxTemp: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "sensor_max_temperature_celsius"),
+			"Maximum temperature of an enclosure sensor since power on",
+			[]string{"conID", "enclosureID", "sensorID", "location"},
+			nil,
+		),
+	}
+}
+
+// LastRefresh returns when the cached data was last refreshed, or the zero
+// time if it never was
+func (c *SsacliEnclosureCollector) LastRefresh() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lastCollect
+}
+
+// Describe return all description to chanel
+func (c *SsacliEnclosureCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+// Refresh runs ssacli and replaces the cached data. The error is that of
+// the invocation, the data is dropped when it fails: enclosures may have
+// been detached, and would otherwise be reported forever.
+func (c *SsacliEnclosureCollector) Refresh(ctx context.Context) error {
+	level.Info(c.logger).Log("msg", "SsacliEnclosureCollector: Invoking ssacli binary", "ssacliPath", c.ssacliPath)
+	out, err := c.runner.Run(ctx, c.ssacliPath, "ctrl", "slot="+c.ConID, "enclosure", "all", "show", "detail")
+	level.Debug(c.logger).Log("msg", "SsacliEnclosureCollector: ssacli ctrl slot=N enclosure all show detail", "conId", c.ConID, "out", out)
+
+	if err != nil {
+		level.Error(c.logger).Log("msg", "Failed to execute shell command", "conId", c.ConID, "out", out, "err", err)
+		if ctx.Err() == nil {
+			c.Clear()
+		}
+		return err
+	}
+
+	data, err := parser.ParseSsacliEnclosure(string(out))
+	countParseErrors(c.logger, "parseSsacliEnclosure", err)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cachedData = data
+	c.lastCollect = time.Now()
+	return nil
+}
+
+// Clear drops the data of the last refresh
+func (c *SsacliEnclosureCollector) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cachedData = nil
+	c.lastCollect = time.Time{}
+}
+
+// SetTopology sets the physical drives of the controller configuration,
+// which tell the occupied bays of each enclosure
+func (c *SsacliEnclosureCollector) SetTopology(physDisks []parser.SsacliConfigPhysDisk) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.physDisks = physDisks
+}
+
+// Collect sends the metrics of the last refresh to the channel
+func (c *SsacliEnclosureCollector) Collect(ch chan<- prometheus.Metric) {
+	level.Debug(c.logger).Log("msg", "SsacliEnclosureCollector: Collect function called")
+
+	c.mu.Lock()
+	data := c.cachedData
+	physDisks := c.physDisks
+	c.mu.Unlock()
+
+	if data == nil {
+		return
+	}
+
+	for _, enclosure := range data.SsacliEnclosureData {
+		collectStateSet(ch, c.status, EnclosureStates, enclosure.Status, c.ConID, enclosure.ID)
+		ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1, c.ConID, enclosure.ID, enclosure.Name, enclosure.Location, enclosure.VendorID, enclosure.SerialNumber, enclosure.FirmVersion)
+		collectStateSet(ch, c.fanStatus, EnclosureFanStates, enclosure.FanStatus, c.ConID, enclosure.ID)
+		collectStateSet(ch, c.tempStatus, EnclosureTempStates, enclosure.TempStatus, c.ConID, enclosure.ID)
+		collectStateSet(ch, c.powerStatus, EnclosurePowerStates, enclosure.PowerSupplyStatus, c.ConID, enclosure.ID)
+
+		occupied := 0.0
+		for _, physDisk := range physDisks {
+			if port, box, _ := parser.SplitPhysDiskID(physDisk.ID); port == enclosure.Port && box == enclosure.Box {
+				occupied++
+			}
+		}
+		if physDisks != nil {
+			ch <- prometheus.MustNewConstMetric(c.occupiedBays, prometheus.GaugeValue, occupied, c.ConID, enclosure.ID)
+		}
+		if enclosure.DriveBays > 0 && !enclosure.ParseErrs.Failed("Drive Bays") {
+			ch <- prometheus.MustNewConstMetric(c.driveBays, prometheus.GaugeValue, enclosure.DriveBays, c.ConID, enclosure.ID)
+			if physDisks != nil {
+				ch <- prometheus.MustNewConstMetric(c.emptyBays, prometheus.GaugeValue, max(enclosure.DriveBays-occupied, 0), c.ConID, enclosure.ID)
+			}
+		}
+
+		for _, expander := range enclosure.Expanders {
+			ch <- prometheus.MustNewConstMetric(c.expanderInfo, prometheus.GaugeValue, 1, c.ConID, enclosure.ID, expander.ID, expander.FirmVersion)
+		}
+
+		for _, sensor := range enclosure.Sensors {
+			if c.sensorReported(enclosure, sensor, "Current Value (C)") {
+				ch <- prometheus.MustNewConstMetric(c.sensorTemp, prometheus.GaugeValue, sensor.CurTemp, c.ConID, enclosure.ID, sensor.ID, sensor.Location)
+			}
+			if c.sensorReported(enclosure, sensor, "Max Value Since Power On") {
+				ch <- prometheus.MustNewConstMetric(c.sensorMaxTemp, prometheus.GaugeValue, sensor.MaxTemp, c.ConID, enclosure.ID, sensor.ID, sensor.Location)
+			}
+		}
+	}
+}
+
+// sensorReported tells whether the sensor holds a valid value for field
+func (c *SsacliEnclosureCollector) sensorReported(enclosure parser.SsacliEnclosureData, sensor parser.SsacliSensorData, field string) bool {
+	_, ok := sensor.Section.Get(field)
+	return ok && !enclosure.ParseErrs.Failed("Sensor "+sensor.ID+" "+field)
+}
@@ -1,7 +1,7 @@
 package collector
 
 import (
-	"os/exec"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log"
@@ -16,24 +16,40 @@ var _ prometheus.Collector = &SsacliLogDiskCollector{}
 type SsacliLogDiskCollector struct {
 	logger log.Logger
 
-	DiskID     string
-	ConID      string
-	ssacliPath string
-	sudoPath   string
+	DiskID string
+	ConID  string
 
-	cachedData  *parser.SsacliLogDisk
+	mu          sync.Mutex
+	cachedData  *parser.SsacliConfigLogDisk
 	lastCollect time.Time
 
-	cylinders *prometheus.Desc
+	// progress follows the operations in progress, by operation name
+	progress map[string]*progress
+
+	cylinders   *prometheus.Desc
+	status      *prometheus.Desc
+	progressPct *prometheus.Desc
+	progressETA *prometheus.Desc
+
+	info              *prometheus.Desc
+	sizeBytes         *prometheus.Desc
+	stripSize         *prometheus.Desc
+	fullStripeSize    *prometheus.Desc
+	heads             *prometheus.Desc
+	sectorsPerTrack   *prometheus.Desc
+	parityInitStatus  *prometheus.Desc
+	multiDomainStatus *prometheus.Desc
 }
 
 // NewSsacliLogDiskCollector Create new collector
-func NewSsacliLogDiskCollector(logger log.Logger, diskID, conID string, ssacliPath string, sudoPath string) *SsacliLogDiskCollector {
+func NewSsacliLogDiskCollector(logger log.Logger, diskID, conID string) *SsacliLogDiskCollector {
 	// Init labels
 	var (
 		namespace = "ssacli"
 		subsystem = "logical_array"
 		labels    = []string{
+			"conID",
+			"diskID",
 			"Size",
 			"Status",
 			"Caching",
@@ -49,17 +65,115 @@ func NewSsacliLogDiskCollector(logger log.Logger, diskID, conID string, ssacliPa
 		logger:      logger,
 		DiskID:      diskID,
 		ConID:       conID,
-		ssacliPath:  ssacliPath,
-		sudoPath:    sudoPath,
 		cachedData:  nil,
-		lastCollect: time.Now(),
+		lastCollect: time.Time{},
+		progress:    make(map[string]*progress),
 		cylinders: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, subsystem, "cylinders"),
 			"Logical array cylinder count",
 			labels,
 			nil,
 		),
+		status: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "status"),
+			"Logical drive status, 1 for the current state and 0 for the others",
+			[]string{"conID", "diskID", "state"},
+			nil,
+		),
+		progressPct: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "operation_progress_percent"),
+			"Completion of an operation in progress on the logical drive: rebuild, transform, expand, erase or parity_init",
+			[]string{"conID", "diskID", "operation"},
+			nil,
+		),
+		progressETA: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "operation_estimated_completion_timestamp_seconds"),
+			"Estimated completion time of an operation in progress on the logical drive, from the rate of progress observed since the exporter first saw it",
+			[]string{"conID", "diskID", "operation"},
+			nil,
+		),
+		info: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "info"),
+			"Logical drive fault tolerance, acceleration method and operating system device, always 1",
+			[]string{"conID", "diskID", "faultTolerance", "accelerationMethod", "osDevice"},
+			nil,
+		),
+		sizeBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "size_bytes"),
+			"Logical drive size",
+			[]string{"conID", "diskID"},
+			nil,
+		),
+		stripSize: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "strip_size_bytes"),
+			"Logical drive strip size",
+			[]string{"conID", "diskID"},
+			nil,
+		),
+		fullStripeSize: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "full_stripe_size_bytes"),
+			"Logical drive full stripe size",
+			[]string{"conID", "diskID"},
+			nil,
+		),
+		heads: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "heads"),
+			"Logical drive head count",
+			[]string{"conID", "diskID"},
+			nil,
+		),
+		sectorsPerTrack: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "sectors_per_track"),
+			"Logical drive sectors per track",
+			[]string{"conID", "diskID"},
+			nil,
+		),
+		parityInitStatus: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "parity_initialization_status"),
+			"Logical drive parity initialization status, 1 for the current state and 0 for the others",
+			[]string{"conID", "diskID", "state"},
+			nil,
+		),
+		multiDomainStatus: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "multidomain_status"),
+			"Logical drive multi-domain status, 1 for the current state and 0 for the others",
+			[]string{"conID", "diskID", "state"},
+			nil,
+		),
+	}
+}
+
+// LastRefresh returns when the cached data was last refreshed, or the zero
+// time if it never was
+func (c *SsacliLogDiskCollector) LastRefresh() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lastCollect
+}
+
+// UID returns the unique identifier of the drive, or an empty string if it
+// was never updated
+func (c *SsacliLogDiskCollector) UID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cachedData == nil {
+		return ""
 	}
+	return c.cachedData.SsacliLogDiskData.UID
+}
+
+// Status returns the status of the drive, or an empty string if it was
+// never updated
+func (c *SsacliLogDiskCollector) Status() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cachedData == nil {
+		return ""
+	}
+	return c.cachedData.SsacliLogDiskData.Status
 }
 
 // Describe return all description to chanel
@@ -67,28 +181,76 @@ func (c *SsacliLogDiskCollector) Describe(ch chan<- *prometheus.Desc) {
 	prometheus.DescribeByCollect(c, ch)
 }
 
+// Update replaces the cached data with the logical drive found in the
+// controller configuration
+func (c *SsacliLogDiskCollector) Update(data parser.SsacliConfigLogDisk) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cachedData = &data
+	c.lastCollect = time.Now()
+	c.observe()
+}
+
+// SetStatus overrides the status of the cached data with the one reported
+// by the cheaper `show status` calls
+func (c *SsacliLogDiskCollector) SetStatus(status string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cachedData == nil {
+		return
+	}
+
+	data := *c.cachedData
+	data.SsacliLogDiskData.Status = status
+	c.cachedData = &data
+	c.observe()
+}
+
+// observe records the progress of the operations of the cached data, and
+// forgets the operations that are over. It must be called with c.mu held.
+func (c *SsacliLogDiskCollector) observe() {
+	percents := logDiskProgress(c.cachedData.SsacliLogDiskData)
+
+	now := time.Now()
+	for op, percent := range percents {
+		p, ok := c.progress[op]
+		if !ok {
+			p = &progress{}
+			c.progress[op] = p
+		}
+		p.observe(now, percent)
+	}
+	for op := range c.progress {
+		if _, ok := percents[op]; !ok {
+			delete(c.progress, op)
+		}
+	}
+}
+
+// Collect sends the metrics of the last refresh to the channel
 func (c *SsacliLogDiskCollector) Collect(ch chan<- prometheus.Metric) {
-	// Export logic raid status
 	level.Debug(c.logger).Log("msg", "SsacliLogDiskCollector: Collect function called")
 
+	c.mu.Lock()
 	data := c.cachedData
-	if c.cachedData == nil || time.Now().After(c.lastCollect.Add(time.Minute)) {
-		level.Info(c.logger).Log("msg", "SsacliLogDiskCollector: Invoking ssacli binary", "ssacliPath", c.ssacliPath)
-		out, err := exec.Command(c.sudoPath, c.ssacliPath, "ctrl", "slot="+c.ConID, "ld", c.DiskID, "show").CombinedOutput()
-		level.Debug(c.logger).Log("msg", "SsacliLogDiskCollector: ssacli ctrl slot=N ld M show", "conID", c.ConID, "diskID", c.DiskID, "out", string(out))
-
-		if err != nil {
-			level.Error(c.logger).Log("msg", "Failed to execute shell command", "out", string(out))
-			return
+	etas := make(map[string]time.Time)
+	for op, p := range c.progress {
+		if eta, ok := p.eta(); ok {
+			etas[op] = eta
 		}
+	}
+	c.mu.Unlock()
 
-		data = parser.ParseSsacliLogDisk(string(out))
-		c.cachedData = data
-		c.lastCollect = time.Now()
+	if data == nil {
+		return
 	}
 
 	var (
 		labels = []string{
+			c.ConID,
+			c.DiskID,
 			data.SsacliLogDiskData.Size,
 			data.SsacliLogDiskData.Status,
 			data.SsacliLogDiskData.Caching,
@@ -98,10 +260,51 @@ func (c *SsacliLogDiskCollector) Collect(ch chan<- prometheus.Metric) {
 		}
 	)
 
-	ch <- prometheus.MustNewConstMetric(
-		c.cylinders,
-		prometheus.GaugeValue,
-		float64(data.SsacliLogDiskData.Cylinders),
-		labels...,
-	)
+	if !data.ParseErrs.Failed("Cylinders") {
+		ch <- prometheus.MustNewConstMetric(
+			c.cylinders,
+			prometheus.GaugeValue,
+			float64(data.SsacliLogDiskData.Cylinders),
+			labels...,
+		)
+	}
+
+	collectStateSet(ch, c.status, LogDiskStates, data.SsacliLogDiskData.Status, c.ConID, c.DiskID)
+
+	d := data.SsacliLogDiskData
+	reported := func(field string) bool {
+		if data.Section == nil {
+			return false
+		}
+		_, ok := data.Section.Get(field)
+		return ok && !data.ParseErrs.Failed(field)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1, c.ConID, c.DiskID, d.FaultTolerance, d.AccelerationMethod, d.LName)
+
+	for _, m := range []struct {
+		desc  *prometheus.Desc
+		field string
+		value float64
+	}{
+		{c.sizeBytes, "Size", d.SizeBytes},
+		{c.stripSize, "Strip Size", d.StripSize},
+		{c.fullStripeSize, "Full Stripe Size", d.FullStripeSize},
+		{c.heads, "Heads", d.Heads},
+		{c.sectorsPerTrack, "Sectors Per Track", d.SectorsPerTrack},
+	} {
+		if reported(m.field) {
+			ch <- prometheus.MustNewConstMetric(m.desc, prometheus.GaugeValue, m.value, c.ConID, c.DiskID)
+		}
+	}
+
+	collectStateSet(ch, c.parityInitStatus, ParityInitStates, d.ParityInitStatus, c.ConID, c.DiskID)
+	collectStateSet(ch, c.multiDomainStatus, MultiDomainStates, d.MultiDomainStatus, c.ConID, c.DiskID)
+
+	for op, percent := range logDiskProgress(data.SsacliLogDiskData) {
+		ch <- prometheus.MustNewConstMetric(c.progressPct, prometheus.GaugeValue, percent, c.ConID, c.DiskID, op)
+		if eta, ok := etas[op]; ok {
+			ch <- prometheus.MustNewConstMetric(c.progressETA, prometheus.GaugeValue, float64(eta.Unix()), c.ConID, c.DiskID, op)
+		}
+	}
 }
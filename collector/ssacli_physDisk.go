@@ -1,7 +1,9 @@
 package collector
 
 import (
-	"os/exec"
+	"math"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log"
@@ -16,25 +18,44 @@ var _ prometheus.Collector = &SsacliPhysDiskCollector{}
 type SsacliPhysDiskCollector struct {
 	logger log.Logger
 
-	DiskID     string
-	ConID      string
-	ssacliPath string
-	sudoPath   string
+	DiskID string
+	ConID  string
 
-	cachedData  *parser.SsacliPhysDisk
+	mu          sync.Mutex
+	cachedData  *parser.SsacliConfigPhysDisk
 	lastCollect time.Time
 
+	// smartctlEndurance is the endurance used reported by smartctl for the
+	// matched disk, when hasSmartctlEndurance is set
+	smartctlEndurance    float64
+	hasSmartctlEndurance bool
+
 	curTemp *prometheus.Desc
 	maxTemp *prometheus.Desc
+	status  *prometheus.Desc
+
+	info              *prometheus.Desc
+	phyCount          *prometheus.Desc
+	phyTransferRate   *prometheus.Desc
+	phyLinkRate       *prometheus.Desc
+	phyMaxLinkRate    *prometheus.Desc
+	rotationalSpeed   *prometheus.Desc
+	exposedToOS       *prometheus.Desc
+	authStatus        *prometheus.Desc
+	sanitizeSupported *prometheus.Desc
+	lastFailureReason *prometheus.Desc
+	enduranceUsed     *prometheus.Desc
+	lifeRemaining     *prometheus.Desc
 }
 
 // NewSsacliPhysDiskCollector Create new collector
-func NewSsacliPhysDiskCollector(logger log.Logger, diskID, conID string, ssacliPath string, sudoPath string) *SsacliPhysDiskCollector {
+func NewSsacliPhysDiskCollector(logger log.Logger, diskID, conID string) *SsacliPhysDiskCollector {
 	// Init labels
 	var (
 		namespace = "ssacli"
 		subsystem = "physical_disk"
 		labels    = []string{
+			"conID",
 			"diskID",
 			"Status",
 			"DriveType",
@@ -51,14 +72,12 @@ func NewSsacliPhysDiskCollector(logger log.Logger, diskID, conID string, ssacliP
 	// Rerutn Colected metric to ch <-
 	// Include labels
 	return &SsacliPhysDiskCollector{
-		logger:     logger,
-		DiskID:     diskID,
-		ConID:      conID,
-		ssacliPath: ssacliPath,
-		sudoPath:   sudoPath,
+		logger: logger,
+		DiskID: diskID,
+		ConID:  conID,
 
 		cachedData:  nil,
-		lastCollect: time.Now(),
+		lastCollect: time.Time{},
 
 		curTemp: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, subsystem, "curTemp"),
@@ -72,7 +91,118 @@ func NewSsacliPhysDiskCollector(logger log.Logger, diskID, conID string, ssacliP
 			labels,
 			nil,
 		),
+		status: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "status"),
+			"Physical disk status, 1 for the current state and 0 for the others",
+			[]string{"conID", "diskID", "state"},
+			nil,
+		),
+		info: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "info"),
+			"Physical disk firmware and carrier versions, always 1",
+			[]string{"conID", "diskID", "firmwareRevision", "carrierApplicationVersion", "carrierBootloaderVersion"},
+			nil,
+		),
+		phyCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "phy_count"),
+			"Physical disk number of PHYs",
+			[]string{"conID", "diskID"},
+			nil,
+		),
+		phyTransferRate: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "phy_transfer_rate_gbps"),
+			"Physical disk negotiated transfer rate of a PHY",
+			[]string{"conID", "diskID", "phy"},
+			nil,
+		),
+		phyLinkRate: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "phy_link_rate_gbps"),
+			"Physical disk physical link rate of a PHY",
+			[]string{"conID", "diskID", "phy"},
+			nil,
+		),
+		phyMaxLinkRate: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "phy_max_link_rate_gbps"),
+			"Physical disk maximum link rate of a PHY",
+			[]string{"conID", "diskID", "phy"},
+			nil,
+		),
+		rotationalSpeed: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "rotational_speed_rpm"),
+			"Physical disk rotational speed",
+			[]string{"conID", "diskID"},
+			nil,
+		),
+		exposedToOS: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "exposed_to_os"),
+			"Whether the physical disk is exposed to the operating system",
+			[]string{"conID", "diskID"},
+			nil,
+		),
+		authStatus: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "authentication_status"),
+			"Physical disk authentication status, 1 for the current state and 0 for the others",
+			[]string{"conID", "diskID", "state"},
+			nil,
+		),
+		sanitizeSupported: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "sanitize_supported"),
+			"Whether the physical disk supports sanitize erase",
+			[]string{"conID", "diskID"},
+			nil,
+		),
+		lastFailureReason: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "last_failure_reason_info"),
+			"Reason of the last failure of the physical disk, always 1",
+			[]string{"conID", "diskID", "reason"},
+			nil,
+		),
+		enduranceUsed: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "endurance_used_percent"),
+			"Percentage of the rated endurance of an SSD that was used, from ssacli or else from smartctl as told by source",
+			[]string{"conID", "diskID", "source"},
+			nil,
+		),
+		lifeRemaining: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "estimated_life_remaining_days"),
+			"Estimated life remaining of an SSD based on its workload to date",
+			[]string{"conID", "diskID"},
+			nil,
+		),
+	}
+}
+
+// LastRefresh returns when the cached data was last refreshed, or the zero
+// time if it never was
+func (c *SsacliPhysDiskCollector) LastRefresh() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lastCollect
+}
+
+// Serial returns the serial number of the drive, or an empty string if it
+// was never updated
+func (c *SsacliPhysDiskCollector) Serial() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cachedData == nil {
+		return ""
+	}
+	return c.cachedData.SsacliPhysDiskData.SN
+}
+
+// Status returns the status of the drive, or an empty string if it was
+// never updated
+func (c *SsacliPhysDiskCollector) Status() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cachedData == nil {
+		return ""
 	}
+	return c.cachedData.SsacliPhysDiskData.Status
 }
 
 // Describe return all description to chanel
@@ -80,28 +210,57 @@ func (c *SsacliPhysDiskCollector) Describe(ch chan<- *prometheus.Desc) {
 	prometheus.DescribeByCollect(c, ch)
 }
 
+// Update replaces the cached data with the physical drive found in the
+// controller configuration
+func (c *SsacliPhysDiskCollector) Update(data parser.SsacliConfigPhysDisk) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cachedData = &data
+	c.lastCollect = time.Now()
+}
+
+// SetStatus overrides the status of the cached data with the one reported
+// by the cheaper `show status` calls
+func (c *SsacliPhysDiskCollector) SetStatus(status string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cachedData == nil {
+		return
+	}
+
+	data := *c.cachedData
+	data.SsacliPhysDiskData.Status = status
+	c.cachedData = &data
+}
+
+// SetSmartctlEndurance sets the endurance used reported by smartctl for the
+// disk matched with the drive, ok is false when there is none
+func (c *SsacliPhysDiskCollector) SetSmartctlEndurance(used float64, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.smartctlEndurance = used
+	c.hasSmartctlEndurance = ok
+}
+
+// Collect sends the metrics of the last refresh to the channel
 func (c *SsacliPhysDiskCollector) Collect(ch chan<- prometheus.Metric) {
-	// Export logic raid status
 	level.Debug(c.logger).Log("msg", "SsacliPhysDiskCollector: Collect function called")
 
+	c.mu.Lock()
 	data := c.cachedData
-	if c.cachedData == nil || time.Now().After(c.lastCollect.Add(time.Minute)) {
-		level.Info(c.logger).Log("msg", "SsacliPhysDiskCollector: Invoking ssacli binary", "ssacliPath", c.ssacliPath)
-		out, err := exec.Command(c.sudoPath, c.ssacliPath, "ctrl", "slot="+c.ConID, "pd", c.DiskID, "show", "detail").CombinedOutput()
-		level.Debug(c.logger).Log("msg", "SsacliPhysDiskCollector: ssacli ctrl slot=N pd M show", "conID", c.ConID, "diskID", c.DiskID, "out", string(out))
-
-		if err != nil {
-			level.Error(c.logger).Log("msg", "Failed to execute shell command", "out", string(out))
-			return
-		}
+	smartctlEndurance, hasSmartctlEndurance := c.smartctlEndurance, c.hasSmartctlEndurance
+	c.mu.Unlock()
 
-		data = parser.ParseSsacliPhysDisk(string(out))
-		c.cachedData = data
-		c.lastCollect = time.Now()
+	if data == nil {
+		return
 	}
 
 	var (
 		labels = []string{
+			c.ConID,
 			c.DiskID,
 			data.SsacliPhysDiskData.Status,
 			data.SsacliPhysDiskData.DriveType,
@@ -115,17 +274,83 @@ func (c *SsacliPhysDiskCollector) Collect(ch chan<- prometheus.Metric) {
 		}
 	)
 
-	ch <- prometheus.MustNewConstMetric(
-		c.curTemp,
-		prometheus.GaugeValue,
-		float64(data.SsacliPhysDiskData.CurTemp),
-		labels...,
-	)
-	ch <- prometheus.MustNewConstMetric(
-		c.maxTemp,
-		prometheus.GaugeValue,
-		float64(data.SsacliPhysDiskData.MaxTemp),
-		labels...,
-	)
-	return
+	if !data.ParseErrs.Failed("Current Temperature (C)") {
+		ch <- prometheus.MustNewConstMetric(
+			c.curTemp,
+			prometheus.GaugeValue,
+			float64(data.SsacliPhysDiskData.CurTemp),
+			labels...,
+		)
+	}
+	if !data.ParseErrs.Failed("Maximum Temperature (C)") {
+		ch <- prometheus.MustNewConstMetric(
+			c.maxTemp,
+			prometheus.GaugeValue,
+			float64(data.SsacliPhysDiskData.MaxTemp),
+			labels...,
+		)
+	}
+
+	collectStateSet(ch, c.status, PhysDiskStates, data.SsacliPhysDiskData.Status, c.ConID, c.DiskID)
+
+	d := data.SsacliPhysDiskData
+	reported := func(field string) bool {
+		if data.Section == nil {
+			return false
+		}
+		_, ok := data.Section.Get(field)
+		return ok && !data.ParseErrs.Failed(field)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1, c.ConID, c.DiskID, d.FirmRevision, d.CarrierAppVersion, d.CarrierBootVersion)
+
+	if reported("PHY Count") {
+		ch <- prometheus.MustNewConstMetric(c.phyCount, prometheus.GaugeValue, d.PhyCount, c.ConID, c.DiskID)
+	}
+	if reported("PHY Transfer Rate") {
+		c.collectPhyRates(ch, c.phyTransferRate, d.PhyTransferRate)
+	}
+	if reported("PHY Physical Link Rate") {
+		c.collectPhyRates(ch, c.phyLinkRate, d.PhyLinkRate)
+	}
+	if reported("PHY Maximum Link Rate") {
+		c.collectPhyRates(ch, c.phyMaxLinkRate, d.PhyMaxLinkRate)
+	}
+	if reported("Rotational Speed") {
+		ch <- prometheus.MustNewConstMetric(c.rotationalSpeed, prometheus.GaugeValue, d.RotationalSpeed, c.ConID, c.DiskID)
+	}
+	if reported("Drive exposed to OS") {
+		ch <- prometheus.MustNewConstMetric(c.exposedToOS, prometheus.GaugeValue, boolToFloat(d.ExposedToOS), c.ConID, c.DiskID)
+	}
+	collectStateSet(ch, c.authStatus, PhysDiskAuthStates, d.AuthStatus, c.ConID, c.DiskID)
+	if reported("Sanitize Erase Supported") {
+		ch <- prometheus.MustNewConstMetric(c.sanitizeSupported, prometheus.GaugeValue, boolToFloat(d.SanitizeSupported), c.ConID, c.DiskID)
+	}
+	if d.LastFailureReason != "" {
+		ch <- prometheus.MustNewConstMetric(c.lastFailureReason, prometheus.GaugeValue, 1, c.ConID, c.DiskID, d.LastFailureReason)
+	}
+
+	// ssacli reports the endurance the same way whatever the interface of
+	// the drive, smartctl is only used when it does not. The remaining usage
+	// has two decimals, rounding avoids artifacts of the subtraction.
+	switch {
+	case reported("Usage remaining"):
+		ch <- prometheus.MustNewConstMetric(c.enduranceUsed, prometheus.GaugeValue, math.Round((100-d.UsageRemaining)*100)/100, c.ConID, c.DiskID, "ssacli")
+	case hasSmartctlEndurance:
+		ch <- prometheus.MustNewConstMetric(c.enduranceUsed, prometheus.GaugeValue, smartctlEndurance, c.ConID, c.DiskID, "smartctl")
+	}
+	if reported("Estimated Life Remaining based on workload to date") {
+		ch <- prometheus.MustNewConstMetric(c.lifeRemaining, prometheus.GaugeValue, d.LifeRemainingDays, c.ConID, c.DiskID)
+	}
+}
+
+// collectPhyRates sends the rate of each PHY to the channel, PHYs whose rate
+// is unknown are left out
+func (c *SsacliPhysDiskCollector) collectPhyRates(ch chan<- prometheus.Metric, desc *prometheus.Desc, rates []float64) {
+	for phy, rate := range rates {
+		if math.IsNaN(rate) {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, rate, c.ConID, c.DiskID, strconv.Itoa(phy))
+	}
 }
@@ -0,0 +1,67 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/john-craig/smartctl_ssacli_exporter/parser"
+)
+
+// logDiskOperations maps the states of a logical drive reporting the
+// progress of an operation to the name of the operation
+var logDiskOperations = map[string]string{
+	"Recovering":   "rebuild",
+	"Transforming": "transform",
+	"Expanding":    "expand",
+	"Erasing":      "erase",
+}
+
+// progress follows the completion percentage of an operation, to estimate
+// when it completes from the rate observed since it was first seen
+type progress struct {
+	startTime    time.Time
+	startPercent float64
+	lastTime     time.Time
+	lastPercent  float64
+}
+
+// observe records the completion percentage of the operation at now. The
+// estimation starts over when the percentage goes back.
+func (p *progress) observe(now time.Time, percent float64) {
+	switch {
+	case p.startTime.IsZero() || percent < p.lastPercent:
+		p.startTime, p.startPercent = now, percent
+		p.lastTime, p.lastPercent = now, percent
+	case percent > p.lastPercent:
+		p.lastTime, p.lastPercent = now, percent
+	}
+}
+
+// eta returns the estimated completion time, ok is false until the
+// percentage was seen increasing
+func (p *progress) eta() (time.Time, bool) {
+	elapsed := p.lastTime.Sub(p.startTime)
+	done := p.lastPercent - p.startPercent
+	if elapsed <= 0 || done <= 0 {
+		return time.Time{}, false
+	}
+
+	remaining := time.Duration(float64(elapsed) * (100 - p.lastPercent) / done)
+	return p.lastTime.Add(remaining), true
+}
+
+// logDiskProgress returns the completion percentage of each operation in
+// progress on the logical drive, by operation name
+func logDiskProgress(data parser.SsacliLogDiskData) map[string]float64 {
+	percents := make(map[string]float64)
+
+	if state, percent, ok := parser.SsacliProgress(data.Status); ok {
+		if op, known := logDiskOperations[state]; known {
+			percents[op] = percent
+		}
+	}
+	if _, percent, ok := parser.SsacliProgress(data.ParityInitProgress); ok {
+		percents["parity_init"] = percent
+	}
+
+	return percents
+}
@@ -0,0 +1,103 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/john-craig/smartctl_ssacli_exporter/parser"
+)
+
+func TestProgressETA(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	type observation struct {
+		after   time.Duration
+		percent float64
+	}
+
+	tests := []struct {
+		name         string
+		observations []observation
+		want         time.Duration
+		ok           bool
+	}{
+		{
+			name:         "single observation",
+			observations: []observation{{0, 10}},
+			ok:           false,
+		},
+		{
+			name:         "no progress",
+			observations: []observation{{0, 10}, {time.Minute, 10}},
+			ok:           false,
+		},
+		{
+			// 10% in 10 minutes, 60% left
+			name:         "steady rate",
+			observations: []observation{{0, 30}, {10 * time.Minute, 40}},
+			want:         70 * time.Minute,
+			ok:           true,
+		},
+		{
+			// The rate is measured since the first observation
+			name:         "stalled update",
+			observations: []observation{{0, 30}, {10 * time.Minute, 40}, {20 * time.Minute, 40}},
+			want:         70 * time.Minute,
+			ok:           true,
+		},
+		{
+			// A new operation starts over from 5%
+			name:         "restarted",
+			observations: []observation{{0, 30}, {10 * time.Minute, 40}, {20 * time.Minute, 5}, {30 * time.Minute, 10}},
+			want:         30*time.Minute + 180*time.Minute,
+			ok:           true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p progress
+			for _, o := range tt.observations {
+				p.observe(start.Add(o.after), o.percent)
+			}
+			eta, ok := p.eta()
+			if ok != tt.ok {
+				t.Fatalf("eta() ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && !eta.Equal(start.Add(tt.want)) {
+				t.Errorf("eta() = %v, want %v", eta, start.Add(tt.want))
+			}
+		})
+	}
+}
+
+func TestLogDiskProgress(t *testing.T) {
+	tests := []struct {
+		name string
+		data parser.SsacliLogDiskData
+		want map[string]float64
+	}{
+		{name: "ok", data: parser.SsacliLogDiskData{Status: "OK"}, want: map[string]float64{}},
+		{name: "rebuild", data: parser.SsacliLogDiskData{Status: "Recovering, 43% complete"}, want: map[string]float64{"rebuild": 43}},
+		{name: "expand", data: parser.SsacliLogDiskData{Status: "Expanding, 7.5% complete"}, want: map[string]float64{"expand": 7.5}},
+		{name: "unknown operation", data: parser.SsacliLogDiskData{Status: "Polishing, 10% complete"}, want: map[string]float64{}},
+		{
+			name: "parity initialization",
+			data: parser.SsacliLogDiskData{Status: "OK", ParityInitStatus: "In Progress", ParityInitProgress: "17% complete"},
+			want: map[string]float64{"parity_init": 17},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := logDiskProgress(tt.data)
+			if len(got) != len(tt.want) {
+				t.Fatalf("logDiskProgress() = %v, want %v", got, tt.want)
+			}
+			for op, percent := range tt.want {
+				if got[op] != percent {
+					t.Errorf("logDiskProgress()[%q] = %v, want %v", op, got[op], percent)
+				}
+			}
+		})
+	}
+}
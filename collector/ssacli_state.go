@@ -0,0 +1,133 @@
+package collector
+
+import (
+	"slices"
+
+	"github.com/john-craig/smartctl_ssacli_exporter/parser"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The states ssacli reports for each kind of object. A state set metric has
+// a series for each of them, plus one for the current state when it is not
+// in the list.
+var (
+	ControllerStates = []string{
+		"OK",
+		"Failed",
+		"Not Redundant",
+		"Unknown",
+	}
+	ArrayStates = []string{
+		"OK",
+		"Failed",
+		"Failed Physical Drive",
+		"Interim Recovery Mode",
+		"Recovering",
+		"Transforming",
+		"Expanding",
+		"Unknown",
+	}
+	LogDiskStates = []string{
+		"OK",
+		"Failed",
+		"Interim Recovery Mode",
+		"Ready for Rebuild",
+		"Recovering",
+		"Transforming",
+		"Expanding",
+		"Queued for Expansion",
+		"Wrong Physical Drive Replaced",
+		"Physical Drive Improperly Connected",
+		"Overheating",
+		"Overheated",
+		"Not Yet Available",
+		"Erasing",
+		"Disabled",
+		"Unknown",
+	}
+	PhysDiskStates = []string{
+		"OK",
+		"Failed",
+		"Predictive Failure",
+		"Rebuilding",
+		"Erasing",
+		"Erase Complete",
+		"Erase Queued",
+		"Unknown",
+	}
+	ParityInitStates = []string{
+		"Initialization Completed",
+		"In Progress",
+		"Queued",
+		"Initialization Failed",
+	}
+	MultiDomainStates = []string{
+		"OK",
+		"Degraded",
+	}
+	PhysDiskAuthStates = []string{
+		"OK",
+		"Failed",
+		"Not Applicable",
+	}
+	EnclosureStates = []string{
+		"OK",
+		"Failed",
+		"Degraded",
+		"Unknown",
+	}
+	EnclosureFanStates = []string{
+		"OK",
+		"Failed",
+		"Degraded",
+		"Not Redundant",
+		"Unknown",
+	}
+	EnclosureTempStates = []string{
+		"OK",
+		"Failed",
+		"Critical",
+		"Unknown",
+	}
+	EnclosurePowerStates = []string{
+		"Redundant",
+		"Not Redundant",
+		"Failed",
+		"Unknown",
+	}
+)
+
+// States return the known states followed by the state of status when it
+// is not one of them
+func States(known []string, status string) []string {
+	state := parser.SsacliState(status)
+	if state == "" || slices.Contains(known, state) {
+		return known
+	}
+	return append(slices.Clone(known), state)
+}
+
+// collectStateSet sends a series per state to the channel, 1 for the state
+// of status and 0 for the others. The state is the last label of desc.
+// Nothing is sent when status is empty.
+func collectStateSet(ch chan<- prometheus.Metric, desc *prometheus.Desc, known []string, status string, labels ...string) {
+	if status == "" {
+		return
+	}
+
+	state := parser.SsacliState(status)
+	for _, s := range States(known, status) {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, append(labels, s)...)
+	}
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
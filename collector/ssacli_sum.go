@@ -1,10 +1,12 @@
 package collector
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os/exec"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log"
@@ -15,19 +17,24 @@ import (
 
 var _ prometheus.Collector = &SsacliSumCollector{}
 
+// errNoController is returned when the ssacli output holds no controller,
+// e.g. when it is truncated. The previous configuration is kept.
+var errNoController = errors.New("no controller found in ssacli output")
+
 // SsacliSumCollector Contain raid controller detail information
 type SsacliSumCollector struct {
 	logger log.Logger
+	runner Runner
 
 	ssacliPath string
 	lsscsiPath string
-	sudoPath   string
 
-	cachedData  *parser.SsacliSum
+	mu          sync.Mutex
+	cachedData  *parser.SsacliConfig
 	lastCollect time.Time
 
-	ConIDs  []string
-	ConDevs []string
+	conIDs  []string
+	conDevs []string
 
 	hwConSlotDesc      *prometheus.Desc
 	cacheSizeDesc      *prometheus.Desc
@@ -35,14 +42,47 @@ type SsacliSumCollector struct {
 	hwConTempDesc      *prometheus.Desc
 	cacheModuTempDesc  *prometheus.Desc
 	batteryTempDesc    *prometheus.Desc
+	statusDesc         *prometheus.Desc
+
+	infoDesc              *prometheus.Desc
+	cacheBoardPresentDesc *prometheus.Desc
+	cacheRatioDesc        *prometheus.Desc
+	surfaceScanDelayDesc  *prometheus.Desc
+	portsDesc             *prometheus.Desc
+	settings              []controllerSetting
+
+	batteryCountDesc             *prometheus.Desc
+	cacheStatusDetailsDesc       *prometheus.Desc
+	writeCacheDisabledByBattDesc *prometheus.Desc
+}
+
+// controllerSetting is an enumerated setting of a controller, exported as a
+// state set
+type controllerSetting struct {
+	desc  *prometheus.Desc
+	known []string
+	value func(parser.SsacliSumData) string
+}
+
+func newControllerSetting(namespace, subsystem, name, help string, known []string, value func(parser.SsacliSumData) string) controllerSetting {
+	return controllerSetting{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, name),
+			help+", 1 for the current state and 0 for the others",
+			[]string{"conID", "state"},
+			nil,
+		),
+		known: known,
+		value: value,
+	}
 }
 
 // NewSsacliSumCollector Create new collector
 func NewSsacliSumCollector(
 	logger log.Logger,
+	runner Runner,
 	ssacliPath string,
-	lsscsiPath string,
-	sudoPath string) *SsacliSumCollector {
+	lsscsiPath string) *SsacliSumCollector {
 	// Init labels
 	var (
 		namespace = "ssacli"
@@ -61,16 +101,16 @@ func NewSsacliSumCollector(
 	// Include labels
 	return &SsacliSumCollector{
 		logger: logger,
+		runner: runner,
 
 		ssacliPath: ssacliPath,
 		lsscsiPath: lsscsiPath,
-		sudoPath:   sudoPath,
 
-		ConIDs:  make([]string, 0),
-		ConDevs: make([]string, 0),
+		conIDs:  make([]string, 0),
+		conDevs: make([]string, 0),
 
 		cachedData:  nil,
-		lastCollect: time.Now(),
+		lastCollect: time.Time{},
 
 		hwConSlotDesc: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, subsystem, "slot"),
@@ -80,13 +120,13 @@ func NewSsacliSumCollector(
 		),
 		cacheSizeDesc: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, subsystem, "cacheSize"),
-			"Hardware raid controller total cache size",
+			"Hardware raid controller total cache size in bytes",
 			labels,
 			nil,
 		),
 		availCacheSizeDesc: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, subsystem, "available_cacheSize"),
-			"Hardware raid controller total available cache size",
+			"Hardware raid controller total available cache size in bytes",
 			labels,
 			nil,
 		),
@@ -108,123 +148,332 @@ func NewSsacliSumCollector(
 			labels,
 			nil,
 		),
+		statusDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "status"),
+			"Hardware raid controller status, 1 for the current state and 0 for the others",
+			[]string{"conID", "state"},
+			nil,
+		),
+		infoDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "info"),
+			"Hardware raid controller model and hardware revision, always 1",
+			[]string{"conID", "model", "hardwareRevision"},
+			nil,
+		),
+		cacheBoardPresentDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "cache_board_present"),
+			"Whether the hardware raid controller has a cache board",
+			[]string{"conID"},
+			nil,
+		),
+		cacheRatioDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "cache_ratio_percent"),
+			"Share of the hardware raid controller cache used for reads or writes",
+			[]string{"conID", "type"},
+			nil,
+		),
+		surfaceScanDelayDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "surface_scan_delay_seconds"),
+			"Idle time after which the hardware raid controller starts a surface scan",
+			[]string{"conID"},
+			nil,
+		),
+		portsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "ports"),
+			"Hardware raid controller number of ports",
+			[]string{"conID"},
+			nil,
+		),
+		batteryCountDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "battery_count"),
+			"Hardware raid controller number of batteries or capacitors backing the cache",
+			[]string{"conID"},
+			nil,
+		),
+		cacheStatusDetailsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "cache_status_details_info"),
+			"Reason given by the hardware raid controller for its cache status, e.g. why the cache is temporarily disabled, always 1",
+			[]string{"conID", "details"},
+			nil,
+		),
+		writeCacheDisabledByBattDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "write_cache_disabled_by_battery"),
+			"Whether the hardware raid controller write cache is off because its batteries or capacitors cannot back it up",
+			[]string{"conID"},
+			nil,
+		),
+		settings: []controllerSetting{
+			newControllerSetting(namespace, subsystem, "mode", "Hardware raid controller mode",
+				[]string{"RAID", "HBA", "Mixed"},
+				func(d parser.SsacliSumData) string { return d.Mode }),
+			newControllerSetting(namespace, subsystem, "cache_status", "Hardware raid controller cache status",
+				[]string{"OK", "Temporarily Disabled", "Permanently Disabled", "Not Configured", "Failed"},
+				func(d parser.SsacliSumData) string { return d.CacheStatus }),
+			newControllerSetting(namespace, subsystem, "drive_write_cache", "Hardware raid controller physical drive write cache policy",
+				[]string{"Enabled", "Disabled", "Default", "Unchanged"},
+				func(d parser.SsacliSumData) string { return d.DriveWriteCache }),
+			newControllerSetting(namespace, subsystem, "no_battery_write_cache", "Hardware raid controller write cache without battery",
+				[]string{"Enabled", "Disabled"},
+				func(d parser.SsacliSumData) string { return d.NoBatteryWriteCache }),
+			newControllerSetting(namespace, subsystem, "rebuild_priority", "Hardware raid controller rebuild priority",
+				[]string{"Low", "Medium", "Medium High", "High", "RapidLow", "RapidMediumHigh", "RapidHigh"},
+				func(d parser.SsacliSumData) string { return d.RebuildPriority }),
+			newControllerSetting(namespace, subsystem, "expand_priority", "Hardware raid controller expand priority",
+				[]string{"Low", "Medium", "High"},
+				func(d parser.SsacliSumData) string { return d.ExpandPriority }),
+			newControllerSetting(namespace, subsystem, "surface_scan_mode", "Hardware raid controller surface scan mode",
+				[]string{"Idle", "High", "Disabled"},
+				func(d parser.SsacliSumData) string { return d.SurfaceScanMode }),
+			newControllerSetting(namespace, subsystem, "spare_activation_mode", "Hardware raid controller spare activation mode",
+				[]string{"Activate on physical drive failure (default)", "Activate on predictive physical drive failure"},
+				func(d parser.SsacliSumData) string { return d.SpareActivationMode }),
+			newControllerSetting(namespace, subsystem, "power_mode", "Hardware raid controller power mode",
+				[]string{"MinPower", "Balanced", "MaxPerformance"},
+				func(d parser.SsacliSumData) string { return d.PowerMode }),
+			newControllerSetting(namespace, subsystem, "survival_mode", "Hardware raid controller survival mode",
+				[]string{"Enabled", "Disabled"},
+				func(d parser.SsacliSumData) string { return d.SurvivalMode }),
+			newControllerSetting(namespace, subsystem, "backup_power_source", "Hardware raid controller cache backup power source",
+				[]string{"Batteries", "Capacitors", "None"},
+				func(d parser.SsacliSumData) string { return d.BackupPowerSource }),
+			newControllerSetting(namespace, subsystem, "battery_status", "Hardware raid controller battery/capacitor status, including its charge",
+				[]string{"OK", "Recharging", "Not Fully Charged", "Failed", "Not Present"},
+				func(d parser.SsacliSumData) string { return d.BatteryStatus }),
+		},
 	}
 }
 
+// LastRefresh returns when the cached data was last refreshed, or the zero
+// time if it never was
+func (c *SsacliSumCollector) LastRefresh() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lastCollect
+}
+
 // Describe return all description to chanel
 func (c *SsacliSumCollector) Describe(ch chan<- *prometheus.Desc) {
 	prometheus.DescribeByCollect(c, ch)
 }
 
-func (c *SsacliSumCollector) Collect(ch chan<- prometheus.Metric) {
-	level.Debug(c.logger).Log("msg", "SsacliSumCollector: Collect function called")
+// Controllers returns the slot IDs of the controllers reported by ssacli and
+// the sg devices of the storage controllers reported by lsscsi
+func (c *SsacliSumCollector) Controllers() ([]string, []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	data := c.cachedData
-	if c.cachedData == nil || time.Now().After(c.lastCollect.Add(time.Minute)) {
-		c.ConIDs = make([]string, 0)
-		c.ConDevs = make([]string, 0)
+	return slices.Clone(c.conIDs), slices.Clone(c.conDevs)
+}
 
-		level.Info(c.logger).Log("msg", "SsacliSumCollector: Invoking ssacli binary", "ssacliPath", c.ssacliPath)
-		out, err := exec.Command(c.sudoPath, c.ssacliPath, "ctrl", "all", "show", "detail").CombinedOutput()
-		level.Debug(c.logger).Log("msg", "SsacliSumCollector: ssacli ctrl all show detail", "out", out)
+// Config returns the topology of the controllers of the last refresh, or nil
+// if there was none. It must not be modified.
+func (c *SsacliSumCollector) Config() *parser.SsacliConfig {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-		if err != nil {
-			level.Error(c.logger).Log("msg", "Failed to execute shell command", "out", out)
-			return
-		}
+	return c.cachedData
+}
 
-		data = parser.ParseSsacliSum(string(out))
+// Refresh runs ssacli and replaces the cached data. The error is that of
+// the invocation, or errNoController when the output holds no controller;
+// the data is kept when it fails.
+func (c *SsacliSumCollector) Refresh(ctx context.Context) error {
+	level.Debug(c.logger).Log("msg", "SsacliSumCollector: Refresh function called")
 
-		for i := range data.SsacliSumData {
-			if !slices.Contains(c.ConIDs, data.SsacliSumData[i].SlotID) {
-				c.ConIDs = append(c.ConIDs, data.SsacliSumData[i].SlotID)
-			}
-		}
+	conIDs := make([]string, 0)
+
+	level.Info(c.logger).Log("msg", "SsacliSumCollector: Invoking ssacli binary", "ssacliPath", c.ssacliPath)
+	out, err := c.runner.Run(ctx, c.ssacliPath, "ctrl", "all", "show", "config", "detail")
+	level.Debug(c.logger).Log("msg", "SsacliSumCollector: ssacli ctrl all show config detail", "out", out)
+
+	if err != nil {
+		level.Error(c.logger).Log("msg", "Failed to execute shell command", "out", out, "err", err)
+		return err
+	}
 
-		// Use the `lsscsi -g` command to determine which controllers
-		// correspond to which /dev/sga path
-		level.Info(c.logger).Log("msg", "SsacliSumCollector: Invoking lsscsi binary", "lsscsiPath", c.lsscsiPath)
-		out, err = exec.Command(c.lsscsiPath, "-g").CombinedOutput()
-		level.Debug(c.logger).Log("msg", "SsacliSumCollector: lsscsi -g", "out", out)
+	data, err := parser.ParseSsacliConfig(string(out))
+	countParseErrors(c.logger, "parseSsacliConfig", err)
 
-		if err != nil {
-			level.Error(c.logger).Log("msg", "Failed to execute shell command", "out", out)
-			return
+	if len(data.Controllers) == 0 {
+		level.Error(c.logger).Log("msg", "SsacliSumCollector: No controller found, keeping the previous configuration", "out", out)
+		return errNoController
+	}
+
+	for _, con := range data.Controllers {
+		if !slices.Contains(conIDs, con.SlotID) {
+			conIDs = append(conIDs, con.SlotID)
 		}
+	}
 
-		scsiDisks := strings.Split(string(out), "\n")
-		for _, scsiDisk := range scsiDisks {
-			scsiFields := strings.Fields(scsiDisk)
-			if len(scsiFields) != 7 {
-				continue
-			}
+	level.Debug(c.logger).Log("msg", "SsacliSumCollector: Refresh completed", "data", fmt.Sprintf("%+v", data), "conIDs", fmt.Sprintf("%+v", conIDs))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.conDevs) > 0 && len(conIDs) != len(c.conDevs) {
+		level.Warn(c.logger).Log("msg", "hpssacli and lsscsi returned different number of controllers")
+	}
+
+	c.cachedData = data
+	c.conIDs = conIDs
+	c.lastCollect = time.Now()
+	return nil
+}
+
+// RefreshDevices runs lsscsi to determine which sg device corresponds to
+// each controller. The devices are kept when it fails.
+func (c *SsacliSumCollector) RefreshDevices(ctx context.Context) error {
+	conDevs := make([]string, 0)
+
+	// Use the `lsscsi -g` command to determine which controllers
+	// correspond to which /dev/sga path
+	level.Info(c.logger).Log("msg", "SsacliSumCollector: Invoking lsscsi binary", "lsscsiPath", c.lsscsiPath)
+	out, err := c.runner.Run(ctx, c.lsscsiPath, "-g")
+	level.Debug(c.logger).Log("msg", "SsacliSumCollector: lsscsi -g", "out", out)
 
-			if scsiFields[1] == "storage" {
-				if !slices.Contains(c.ConDevs, scsiFields[6]) {
-					c.ConDevs = append(c.ConDevs, scsiFields[6])
-				}
+	if err != nil {
+		level.Error(c.logger).Log("msg", "Failed to execute shell command", "out", out, "err", err)
+		return err
+	}
+
+	scsiDisks := strings.Split(string(out), "\n")
+	for _, scsiDisk := range scsiDisks {
+		scsiFields := strings.Fields(scsiDisk)
+		if len(scsiFields) != 7 {
+			continue
+		}
+
+		if scsiFields[1] == "storage" {
+			if !slices.Contains(conDevs, scsiFields[6]) {
+				conDevs = append(conDevs, scsiFields[6])
 			}
 		}
+	}
+
+	level.Debug(c.logger).Log("msg", "SsacliSumCollector: RefreshDevices completed", "conDevs", fmt.Sprintf("%+v", conDevs))
 
-		c.cachedData = data
-		c.lastCollect = time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.conIDs) != len(conDevs) {
+		level.Warn(c.logger).Log("msg", "hpssacli and lsscsi returned different number of controllers")
+	}
+
+	c.conDevs = conDevs
+	return nil
+}
+
+// Collect sends the metrics of the last refresh to the channel
+func (c *SsacliSumCollector) Collect(ch chan<- prometheus.Metric) {
+	level.Debug(c.logger).Log("msg", "SsacliSumCollector: Collect function called")
+
+	c.mu.Lock()
+	data := c.cachedData
+	c.mu.Unlock()
+
+	if data == nil {
+		return
 	}
 
-	for i := range data.SsacliSumData {
+	for _, con := range data.Controllers {
 		var (
 			labels = []string{
-				data.SsacliSumData[i].SerialNumber,
-				data.SsacliSumData[i].ContStatus,
-				data.SsacliSumData[i].FirmVersion,
-				data.SsacliSumData[i].BatteryStatus,
-				data.SsacliSumData[i].Encryption,
-				data.SsacliSumData[i].DriverName,
-				data.SsacliSumData[i].DriverVersion,
+				con.SsacliSumData.SerialNumber,
+				con.SsacliSumData.ContStatus,
+				con.SsacliSumData.FirmVersion,
+				con.SsacliSumData.BatteryStatus,
+				con.SsacliSumData.Encryption,
+				con.SsacliSumData.DriverName,
+				con.SsacliSumData.DriverVersion,
 			}
 		)
 
-		ch <- prometheus.MustNewConstMetric(
-			c.hwConSlotDesc,
-			prometheus.GaugeValue,
-			float64(data.SsacliSumData[i].Slot),
-			labels...,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			c.cacheSizeDesc,
-			prometheus.GaugeValue,
-			float64(data.SsacliSumData[i].TotalCacheSize),
-			labels...,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			c.availCacheSizeDesc,
-			prometheus.GaugeValue,
-			float64(data.SsacliSumData[i].AvailCacheSize),
-			labels...,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			c.hwConTempDesc,
-			prometheus.GaugeValue,
-			float64(data.SsacliSumData[i].ContTemp),
-			labels...,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			c.cacheModuTempDesc,
-			prometheus.GaugeValue,
-			float64(data.SsacliSumData[i].CacheModuTemp),
-			labels...,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			c.batteryTempDesc,
-			prometheus.GaugeValue,
-			float64(data.SsacliSumData[i].BatteryTemp),
-			labels...,
-		)
+		if !con.ParseErrs.Failed("Slot") {
+			ch <- prometheus.MustNewConstMetric(
+				c.hwConSlotDesc,
+				prometheus.GaugeValue,
+				float64(con.SsacliSumData.Slot),
+				labels...,
+			)
+		}
+		if !con.ParseErrs.Failed("Total Cache Size") {
+			ch <- prometheus.MustNewConstMetric(
+				c.cacheSizeDesc,
+				prometheus.GaugeValue,
+				float64(con.SsacliSumData.TotalCacheSize),
+				labels...,
+			)
+		}
+		if !con.ParseErrs.Failed("Total Cache Memory Available") {
+			ch <- prometheus.MustNewConstMetric(
+				c.availCacheSizeDesc,
+				prometheus.GaugeValue,
+				float64(con.SsacliSumData.AvailCacheSize),
+				labels...,
+			)
+		}
+		if !con.ParseErrs.Failed("Controller Temperature (C)") {
+			ch <- prometheus.MustNewConstMetric(
+				c.hwConTempDesc,
+				prometheus.GaugeValue,
+				float64(con.SsacliSumData.ContTemp),
+				labels...,
+			)
+		}
+		if !con.ParseErrs.Failed("Cache Module Temperature (C)") {
+			ch <- prometheus.MustNewConstMetric(
+				c.cacheModuTempDesc,
+				prometheus.GaugeValue,
+				float64(con.SsacliSumData.CacheModuTemp),
+				labels...,
+			)
+		}
+		if !con.ParseErrs.Failed("Capacitor Temperature  (C)") {
+			ch <- prometheus.MustNewConstMetric(
+				c.batteryTempDesc,
+				prometheus.GaugeValue,
+				float64(con.SsacliSumData.BatteryTemp),
+				labels...,
+			)
+		}
 
-	}
+		collectStateSet(ch, c.statusDesc, ControllerStates, con.SsacliSumData.ContStatus, con.SlotID)
 
-	level.Debug(c.logger).Log("msg", "SsacliSumCollector: Collection completed", "data", fmt.Sprintf("%+v", data), "conIDs", fmt.Sprintf("%+v", c.ConIDs), "conDevs", fmt.Sprintf("%+v", c.ConDevs))
+		ch <- prometheus.MustNewConstMetric(c.infoDesc, prometheus.GaugeValue, 1, con.SlotID, con.SsacliSumData.Model, con.SsacliSumData.HardwareRevision)
 
-	if len(c.ConIDs) != len(c.ConDevs) {
-		level.Warn(c.logger).Log("msg", "hpssacli and lsscsi returned different number of controllers")
+		// Older controllers do not report every setting, nothing is
+		// exported for those they leave out
+		reported := func(field string) bool {
+			_, ok := con.Section.Get(field)
+			return ok && !con.ParseErrs.Failed(field)
+		}
+		if reported("Cache Board Present") {
+			ch <- prometheus.MustNewConstMetric(c.cacheBoardPresentDesc, prometheus.GaugeValue, boolToFloat(con.SsacliSumData.CacheBoardPresent), con.SlotID)
+		}
+		if reported("Cache Ratio") {
+			ch <- prometheus.MustNewConstMetric(c.cacheRatioDesc, prometheus.GaugeValue, con.SsacliSumData.CacheRatioRead, con.SlotID, "read")
+			ch <- prometheus.MustNewConstMetric(c.cacheRatioDesc, prometheus.GaugeValue, con.SsacliSumData.CacheRatioWrite, con.SlotID, "write")
+		}
+		if reported("Surface Scan Delay") {
+			ch <- prometheus.MustNewConstMetric(c.surfaceScanDelayDesc, prometheus.GaugeValue, con.SsacliSumData.SurfaceScanDelay, con.SlotID)
+		}
+		if reported("Number of Ports") {
+			ch <- prometheus.MustNewConstMetric(c.portsDesc, prometheus.GaugeValue, con.SsacliSumData.Ports, con.SlotID)
+		}
+
+		if reported("Battery/Capacitor Count") {
+			ch <- prometheus.MustNewConstMetric(c.batteryCountDesc, prometheus.GaugeValue, con.SsacliSumData.BatteryCount, con.SlotID)
+		}
+		if con.SsacliSumData.CacheStatusDetails != "" {
+			ch <- prometheus.MustNewConstMetric(c.cacheStatusDetailsDesc, prometheus.GaugeValue, 1, con.SlotID, con.SsacliSumData.CacheStatusDetails)
+		}
+		if reported("Cache Status") || reported("Battery/Capacitor Status") {
+			ch <- prometheus.MustNewConstMetric(c.writeCacheDisabledByBattDesc, prometheus.GaugeValue, boolToFloat(con.SsacliSumData.WriteCacheDisabledByBattery()), con.SlotID)
+		}
+
+		for _, setting := range c.settings {
+			collectStateSet(ch, setting.desc, setting.known, setting.value(con.SsacliSumData), con.SlotID)
+		}
 	}
 }
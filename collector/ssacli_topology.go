@@ -0,0 +1,151 @@
+package collector
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/john-craig/smartctl_ssacli_exporter/parser"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ prometheus.Collector = &SsacliTopologyCollector{}
+
+// SsacliTopologyCollector Contain the membership of arrays, logical and
+// physical drives of every controller
+type SsacliTopologyCollector struct {
+	logger log.Logger
+
+	mu          sync.Mutex
+	cachedData  *parser.SsacliConfig
+	lastCollect time.Time
+
+	arrayInfo      *prometheus.Desc
+	logDiskInfo    *prometheus.Desc
+	physDiskInfo   *prometheus.Desc
+	logDiskMembers *prometheus.Desc
+	unassigned     *prometheus.Desc
+}
+
+// NewSsacliTopologyCollector Create new collector
+func NewSsacliTopologyCollector(logger log.Logger) *SsacliTopologyCollector {
+	var (
+		namespace = "ssacli"
+		subsystem = "topology"
+	)
+
+	return &SsacliTopologyCollector{
+		logger: logger,
+
+		cachedData:  nil,
+		lastCollect: time.Time{},
+
+		arrayInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "array_info"),
+			"Array of a controller, always 1",
+			[]string{"conID", "arrayID"},
+			nil,
+		),
+		logDiskInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "logical_drive_info"),
+			"Logical drive of an array, always 1",
+			[]string{"conID", "arrayID", "ldID", "UID"},
+			nil,
+		),
+		physDiskInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "physical_drive_info"),
+			"Physical drive of a controller, always 1. arrayID is empty for drives that are not part of an array, whose role is unassigned, or hba for the drives passed through to the host. role is spare for the spares of the array",
+			[]string{"conID", "arrayID", "diskID", "port", "box", "bay", "role", "SN"},
+			nil,
+		),
+		logDiskMembers: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "logical_drive_member_info"),
+			"Physical drive holding data of a logical drive, always 1",
+			[]string{"conID", "arrayID", "ldID", "diskID", "port", "box", "bay"},
+			nil,
+		),
+		unassigned: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "unassigned_drives"),
+			"Number of physical drives of a controller that are not part of any array nor passed through to the host",
+			[]string{"conID"},
+			nil,
+		),
+	}
+}
+
+// LastRefresh returns when the cached data was last refreshed, or the zero
+// time if it never was
+func (c *SsacliTopologyCollector) LastRefresh() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lastCollect
+}
+
+// Describe return all description to chanel
+func (c *SsacliTopologyCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+// Update replaces the cached data with the controller configuration
+func (c *SsacliTopologyCollector) Update(data *parser.SsacliConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cachedData = data
+	c.lastCollect = time.Now()
+}
+
+// Collect sends the metrics of the last refresh to the channel
+func (c *SsacliTopologyCollector) Collect(ch chan<- prometheus.Metric) {
+	level.Debug(c.logger).Log("msg", "SsacliTopologyCollector: Collect function called")
+
+	c.mu.Lock()
+	data := c.cachedData
+	c.mu.Unlock()
+
+	if data == nil {
+		return
+	}
+
+	for _, con := range data.Controllers {
+		for _, array := range con.Arrays {
+			ch <- prometheus.MustNewConstMetric(c.arrayInfo, prometheus.GaugeValue, 1, con.SlotID, array.ID)
+
+			for _, logDisk := range array.LogDisks {
+				ch <- prometheus.MustNewConstMetric(c.logDiskInfo, prometheus.GaugeValue, 1, con.SlotID, array.ID, logDisk.ID, logDisk.SsacliLogDiskData.UID)
+			}
+
+			for _, physDisk := range array.PhysDisks {
+				port, box, bay := parser.SplitPhysDiskID(physDisk.ID)
+
+				role := "data"
+				if strings.Contains(physDisk.SsacliPhysDiskData.DriveType, "Spare") {
+					role = "spare"
+				}
+				ch <- prometheus.MustNewConstMetric(c.physDiskInfo, prometheus.GaugeValue, 1, con.SlotID, array.ID, physDisk.ID, port, box, bay, role, physDisk.SsacliPhysDiskData.SN)
+
+				// Every logical drive of an array is striped over all of
+				// its data drives, spares hold no data until activated
+				if role != "data" {
+					continue
+				}
+				for _, logDisk := range array.LogDisks {
+					ch <- prometheus.MustNewConstMetric(c.logDiskMembers, prometheus.GaugeValue, 1, con.SlotID, array.ID, logDisk.ID, physDisk.ID, port, box, bay)
+				}
+			}
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.unassigned, prometheus.GaugeValue, float64(len(con.Unassigned)), con.SlotID)
+		for _, physDisk := range con.Unassigned {
+			port, box, bay := parser.SplitPhysDiskID(physDisk.ID)
+			ch <- prometheus.MustNewConstMetric(c.physDiskInfo, prometheus.GaugeValue, 1, con.SlotID, "", physDisk.ID, port, box, bay, "unassigned", physDisk.SsacliPhysDiskData.SN)
+		}
+		for _, physDisk := range con.HBADrives {
+			port, box, bay := parser.SplitPhysDiskID(physDisk.ID)
+			ch <- prometheus.MustNewConstMetric(c.physDiskInfo, prometheus.GaugeValue, 1, con.SlotID, "", physDisk.ID, port, box, bay, "hba", physDisk.SsacliPhysDiskData.SN)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package collector
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os/exec"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log"
@@ -17,22 +20,55 @@ var _ prometheus.Collector = &SmartctlDiskCollector{}
 // SmartctlDiskCollector Contain raid controller detail information
 type SmartctlDiskCollector struct {
 	logger log.Logger
+	runner Runner
 
 	smartctlPath string
-	sudoPath     string
 
 	ConID  string
 	ConDev string
 	DiskN  int
 
+	mu          sync.Mutex
 	lastCollect time.Time
 
+	// serial and wwn identify the disk at the cciss index, they are kept
+	// from the last refresh that reported them. present tells whether the
+	// last refresh reported a disk at all.
+	serial  string
+	wwn     string
+	present bool
+
+	// diskID and bay are those of the ssacli physical drive the disk was
+	// matched with
+	diskID string
+	bay    string
+
+	// enduranceUsed is the percentage of the rated endurance used, when
+	// hasEndurance is set
+	enduranceUsed float64
+	hasEndurance  bool
+
 	embed *SMARTctl
 }
 
+var errInvalidJSON = errors.New("smartctl output is not valid JSON")
+
+// smartctlWWN returns the world wide name of the disk in the form ssacli
+// reports WWIDs, i.e. upper case hexadecimal digits
+func smartctlWWN(json gjson.Result) string {
+	if lu := json.Get("logical_unit_id"); lu.Exists() {
+		return strings.ToUpper(strings.TrimPrefix(strings.TrimSpace(lu.String()), "0x"))
+	}
+	if wwn := json.Get("wwn"); wwn.Exists() {
+		return fmt.Sprintf("%X%06X%09X", wwn.Get("naa").Uint(), wwn.Get("oui").Uint(), wwn.Get("id").Uint())
+	}
+	return ""
+}
+
 // Parse json to gjson object
 func parseJSON(data string) gjson.Result {
 	if !gjson.Valid(data) {
+		countParseError("parseJSON", 1)
 		return gjson.Parse("{}")
 	}
 	return gjson.Parse(data)
@@ -41,54 +77,130 @@ func parseJSON(data string) gjson.Result {
 // NewSmartctlDiskCollector Create new collector
 func NewSmartctlDiskCollector(
 	logger log.Logger,
+	runner Runner,
 	conID string,
 	conDev string,
 	diskN int,
-	smartctlPath string,
-	sudoPath string) *SmartctlDiskCollector {
+	smartctlPath string) *SmartctlDiskCollector {
 	level.Debug(logger).Log("msg", "SmartctlDiskCollector: NewSmartctlDiskCollector function called")
 
 	return &SmartctlDiskCollector{
 		logger:       logger,
+		runner:       runner,
 		ConID:        conID,
 		ConDev:       conDev,
 		DiskN:        diskN,
 		smartctlPath: smartctlPath,
-		sudoPath:     sudoPath,
-		lastCollect:  time.Now(),
+		lastCollect:  time.Time{},
 		embed:        nil}
 }
 
+// LastRefresh returns when the cached data was last refreshed, or the zero
+// time if it never was
+func (c *SmartctlDiskCollector) LastRefresh() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lastCollect
+}
+
+// Identity returns the serial number and world wide name of the disk, or
+// empty strings if smartctl never reported them
+func (c *SmartctlDiskCollector) Identity() (string, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.serial, c.wwn
+}
+
+// Present reports whether the last refresh found a disk at the cciss index
+func (c *SmartctlDiskCollector) Present() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.present
+}
+
+// SetDrive sets the ssacli physical drive the disk was matched with, empty
+// strings when it could not be matched
+func (c *SmartctlDiskCollector) SetDrive(diskID, bay string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.diskID = diskID
+	c.bay = bay
+}
+
 // Describe return all description to chanel
 func (c *SmartctlDiskCollector) Describe(ch chan<- *prometheus.Desc) {
 	prometheus.DescribeByCollect(c, ch)
 }
 
+// Refresh runs smartctl and replaces the cached data. An error is returned
+// when smartctl could not be run to completion or its output is not JSON,
+// a non-zero exit status alone is reported by the smartctl metrics instead.
+func (c *SmartctlDiskCollector) Refresh(ctx context.Context) error {
+	level.Info(c.logger).Log("msg", "SmartctlDiskCollector: Invoking smartctl binary", "smartctlPath", c.smartctlPath)
+	out, err := c.runner.Run(ctx, c.smartctlPath, "--json", "--info", "--health", "--attributes", "--tolerance=verypermissive", "--nocheck=standby", "--all", "-d", "cciss,"+strconv.Itoa(c.DiskN), c.ConDev)
+	level.Debug(c.logger).Log("msg", "SmartctlDiskCollector: smartctl --info --health --attributes --tolerance=verypermissive --nocheck=standby --all -d ciss,N /dev/sgM", "diskN", strconv.Itoa(c.DiskN), "conDev", c.ConDev, "out", out)
+
+	if err != nil {
+		level.Error(c.logger).Log("msg", "Failed to execute shell command", "out", string(out))
+	}
+	if err != nil && ExitCode(err) < 0 {
+		return err
+	}
+
+	err = nil
+	if !gjson.Valid(string(out)) {
+		err = errInvalidJSON
+	}
+	json := parseJSON(string(out))
+
+	embed := NewSMARTctl(c.logger, json, c.ConID, c.DiskN, "", "", nil)
+	level.Debug(c.logger).Log("msg", "SmartctlDiskCollector: SmartCTL embed updated", "embed", fmt.Sprintf("%+v", *embed))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.present = embed.device.serial != ""
+	if c.present {
+		c.serial = embed.device.serial
+		c.wwn = smartctlWWN(json)
+	}
+	c.enduranceUsed, c.hasEndurance = EnduranceUsed(json)
+	c.embed = embed
+	c.lastCollect = time.Now()
+	return err
+}
+
+// EnduranceUsed returns the percentage of the rated endurance of the disk
+// that was used, ok is false when the disk does not report it
+func (c *SmartctlDiskCollector) EnduranceUsed() (float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.enduranceUsed, c.hasEndurance
+}
+
 // Collect create collector
 // Get metric
 // Handle error
 func (c *SmartctlDiskCollector) Collect(ch chan<- prometheus.Metric) {
-	if c.embed == nil || time.Now().After(c.lastCollect.Add(time.Minute)) {
-		level.Info(c.logger).Log("msg", "SmartctlDiskCollector: Invoking smartctl binary", "smartctlPath", c.smartctlPath)
-		out, err := exec.Command(c.sudoPath, c.smartctlPath, "--json", "--info", "--health", "--attributes", "--tolerance=verypermissive", "--nocheck=standby", "--all", "-d", "cciss,"+strconv.Itoa(c.DiskN), c.ConDev).CombinedOutput()
-		level.Debug(c.logger).Log("msg", "SmartctlDiskCollector: smartctl --info --health --attributes --tolerance=verypermissive --nocheck=standby --all -d ciss,N /dev/sgM", "diskN", strconv.Itoa(c.DiskN), "conDev", c.ConDev, "out", out)
-
-		if err != nil {
-			level.Error(c.logger).Log("msg", "Failed to execute shell command", "out", string(out))
-		}
-		json := parseJSON(string(out))
-
-		if c.embed == nil {
-			c.embed = NewSMARTctl(c.logger, json, c.ConID, c.DiskN, ch)
-			level.Debug(c.logger).Log("msg", "SmartctlDiskCollector: New SmartCTL embed created", "embed", fmt.Sprintf("%+v", *c.embed))
-		} else {
-			c.embed.json = json
-			level.Debug(c.logger).Log("msg", "SmartctlDiskCollector: SmartCTL embed updated", "embed", fmt.Sprintf("%+v", *c.embed))
-		}
-
-		c.lastCollect = time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.embed == nil {
+		return
 	}
 
+	// The channel differs between scrapes and the drive may be matched
+	// after the refresh, so both are handed to the embed right before
+	// collecting
+	c.embed.ch = ch
+	c.embed.device.ssacli_disk_id = c.diskID
+	c.embed.device.ssacli_bay = c.bay
+
 	level.Debug(c.logger).Log("msg", "SmartctlDiskCollector: Invoking Collect function of SMARTctl embed", "embed", fmt.Sprintf("%+v", *c.embed))
 	c.embed.Collect()
 }
@@ -1,13 +1,19 @@
 package exporter
 
 import (
-	"os/exec"
+	"context"
+	"errors"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/john-craig/smartctl_ssacli_exporter/collector"
+	"github.com/john-craig/smartctl_ssacli_exporter/parser"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -17,57 +23,153 @@ import (
 //
 // It implements the exporter.Collector interface in order to register
 // with Prometheus.
+//
+// Data is refreshed in the background by Start, or on demand by Refresh;
+// Collect only ever reports the latest refreshed data. Sources configured
+// with an interval of 0 are instead refreshed during each scrape, see
+// ScrapeCollector.
 type Exporter struct {
+	runner collector.Runner
+
 	smartctlPath string
 	ssacliPath   string
 	lsscsiPath   string
-	sudoPath     string
 
-	sumCol   collector.SsacliSumCollector
-	physCols []collector.SsacliPhysDiskCollector
-	logCols  []collector.SsacliLogDiskCollector
-	smrtCols []collector.SmartctlDiskCollector
+	sources         []*source
+	smartctlWorkers int
+
+	mu       sync.Mutex
+	sumCol   *collector.SsacliSumCollector
+	topoCol  *collector.SsacliTopologyCollector
+	physCols []*collector.SsacliPhysDiskCollector
+	logCols  []*collector.SsacliLogDiskCollector
+	smrtCols []*collector.SmartctlDiskCollector
+	arrCols  []*collector.SsacliArrayCollector
+	encCols  []*collector.SsacliEnclosureCollector
 
 	conIDs  []string
 	conDevs []string
 
-	cachedPhysDiskLines [][]string
-	cachedLogDiskLines  [][]string
+	// probed is the cciss index up to which smartctl was probed, per sg
+	// device
+	probed map[string]int
+	// unmatched are the disks of the last matching of smartctl disks with
+	// ssacli physical drives that could not be matched
+	unmatched []unmatchedDisk
+	// tombstones are the drives removed by reconcile, kept for
+	// tombstoneLifetime
+	tombstones        []tombstone
+	tombstoneLifetime time.Duration
+	// forcedStatusIDs are the drives reported by the status calls when they
+	// last forced a refresh of the configuration. A configuration that
+	// keeps disagreeing with them is not refreshed again until they change.
+	forcedStatusIDs string
+
+	health *health
 
 	logger log.Logger
 }
 
+// unmatchedDisk is a disk reported by only one of smartctl and ssacli
+type unmatchedDisk struct {
+	conID  string
+	source string
+	id     string
+}
+
+// ccissProbeExtra is how many cciss indexes past the number of physical
+// drives of a controller are probed for its unmatched drives
+const ccissProbeExtra = 8
+
+// errNoDevice is reported for the controllers whose sg device is unknown,
+// smartctl cannot be run for their disks
+var errNoDevice = errors.New("no sg device known for controller")
+
 var _ prometheus.Collector = &Exporter{}
 
-// New creates a new Exporter which collects metrics by creating a apcupsd
-// client using the input ClientFunc.
+// New creates a new Exporter which collects metrics by invoking the
+// smartctl, ssacli and lsscsi binaries through the given runner.
 func New(
 	logger log.Logger,
+	runner collector.Runner,
 	smartctlPath string,
 	ssacliPath string,
 	lsscsiPath string,
-	sudoPath string) *Exporter {
+	intervals Intervals,
+	smartctlWorkers int,
+	tombstoneLifetime time.Duration) *Exporter {
 
-	sumCol := collector.NewSsacliSumCollector(logger, ssacliPath, lsscsiPath, sudoPath)
+	sumCol := collector.NewSsacliSumCollector(logger, runner, ssacliPath, lsscsiPath)
 
-	return &Exporter{
+	e := &Exporter{
 		logger: logger,
+		runner: runner,
 
-		sumCol:   *sumCol,
-		physCols: make([]collector.SsacliPhysDiskCollector, 0),
-		logCols:  make([]collector.SsacliLogDiskCollector, 0),
-		smrtCols: make([]collector.SmartctlDiskCollector, 0),
+		sumCol:   sumCol,
+		topoCol:  collector.NewSsacliTopologyCollector(logger),
+		physCols: make([]*collector.SsacliPhysDiskCollector, 0),
+		logCols:  make([]*collector.SsacliLogDiskCollector, 0),
+		smrtCols: make([]*collector.SmartctlDiskCollector, 0),
+		arrCols:  make([]*collector.SsacliArrayCollector, 0),
+		encCols:  make([]*collector.SsacliEnclosureCollector, 0),
 
 		conIDs:  make([]string, 0),
 		conDevs: make([]string, 0),
 
-		cachedPhysDiskLines: make([][]string, 0),
-		cachedLogDiskLines:  make([][]string, 0),
+		probed:    make(map[string]int),
+		unmatched: make([]unmatchedDisk, 0),
+
+		tombstones:        make([]tombstone, 0),
+		tombstoneLifetime: tombstoneLifetime,
+
+		health: newHealth(),
+
+		smartctlWorkers: max(smartctlWorkers, 1),
 
 		smartctlPath: smartctlPath,
 		ssacliPath:   ssacliPath,
-		lsscsiPath:   lsscsiPath,
-		sudoPath:     sudoPath}
+		lsscsiPath:   lsscsiPath}
+
+	// The drives must be discovered from the controller configuration before
+	// their status can be updated and smartctl run, so the order matters.
+	e.sources = []*source{
+		{name: "detail", interval: intervals.Detail, refresh: e.refreshDetail},
+		{name: "status", interval: intervals.Status, refresh: e.refreshStatus},
+		{name: "smartctl", interval: intervals.Smartctl, refresh: e.refreshSmartctl},
+	}
+
+	return e
+}
+
+// Start refreshes every data source with a non-zero interval in the
+// background, each on its own interval, until ctx is cancelled.
+func (e *Exporter) Start(ctx context.Context) {
+	s := newScheduler(e.logger)
+	for _, src := range e.sources {
+		if src.interval > 0 {
+			s.add(src.name, src.interval, src.run)
+		}
+	}
+	s.start(ctx)
+}
+
+// Refresh refreshes every data source once, synchronously.
+func (e *Exporter) Refresh(ctx context.Context) {
+	for _, src := range e.sources {
+		src.run(ctx)
+	}
+}
+
+// ScrapeCollector returns a collector for a single scrape. It refreshes the
+// sources configured with an interval of 0 under ctx before reporting the
+// metrics of every source. Once ctx is done the remaining work is skipped,
+// the previous data is reported for it and its source is marked as timed
+// out.
+//
+// The returned collector describes no metrics, it is meant to be registered
+// with a registry created for the scrape.
+func (e *Exporter) ScrapeCollector(ctx context.Context) prometheus.Collector {
+	return &scrapeCollector{exporter: e, ctx: ctx}
 }
 
 // Describe sends all the descriptors of the collectors included to
@@ -81,136 +183,730 @@ func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 	level.Debug(e.logger).Log("msg", "Exporter: Collect function called")
 	e.sumCol.Collect(ch)
-	conIDs := e.sumCol.ConIDs
-	conDevs := e.sumCol.ConDevs
+	e.topoCol.Collect(ch)
+
+	e.mu.Lock()
+	physCols := slices.Clone(e.physCols)
+	logCols := slices.Clone(e.logCols)
+	smrtCols := slices.Clone(e.smrtCols)
+	arrCols := slices.Clone(e.arrCols)
+	encCols := slices.Clone(e.encCols)
+	unmatched := slices.Clone(e.unmatched)
+	tombstones := slices.Clone(e.tombstones)
+	e.mu.Unlock()
+
+	for _, arrCol := range arrCols {
+		arrCol.Collect(ch)
+	}
 
-	if !reflect.DeepEqual(e.conIDs, conIDs) || !reflect.DeepEqual(e.conDevs, conDevs) {
-		// If the controllers changed, fix 'em
-		e.physCols = make([]collector.SsacliPhysDiskCollector, 0)
-		e.logCols = make([]collector.SsacliLogDiskCollector, 0)
-		e.smrtCols = make([]collector.SmartctlDiskCollector, 0)
+	for _, encCol := range encCols {
+		encCol.Collect(ch)
+	}
 
-		e.cachedLogDiskLines = make([][]string, len(e.conIDs))
-		e.cachedPhysDiskLines = make([][]string, len(e.conIDs))
+	for _, physCol := range physCols {
+		physCol.Collect(ch)
+	}
 
-		e.conIDs = conIDs
-		e.conDevs = conDevs
+	for _, smrtCol := range smrtCols {
+		smrtCol.Collect(ch)
 	}
 
-	for i := 0; i < len(conIDs); i++ {
-		conID := conIDs[i]
-		conDev := conDevs[i]
+	for _, logCol := range logCols {
+		logCol.Collect(ch)
+	}
 
-		level.Info(e.logger).Log("msg", "Exporter: Invoking ssacli binary", "ssacliPath", e.ssacliPath)
-		out, err := exec.Command(e.sudoPath, e.ssacliPath, "ctrl", "slot="+conID, "pd", "all", "show", "status").CombinedOutput()
-		level.Debug(e.logger).Log("msg", "Exporter: ssacli ctrl slot=N pd all show status", "conId", conID, "out", out)
+	for _, disk := range unmatched {
+		ch <- prometheus.MustNewConstMetric(unmatchedDiskDesc, prometheus.GaugeValue, 1, disk.conID, disk.source, disk.id)
+	}
 
-		if err != nil {
-			level.Error(e.logger).Log("msg", "Failed collecting metric", "out", out, "err", err)
-			return
+	for _, t := range tombstones {
+		if time.Since(t.lastSeen) > e.tombstoneLifetime {
+			continue
 		}
+		ch <- prometheus.MustNewConstMetric(tombstoneDesc, prometheus.GaugeValue, float64(t.lastSeen.Unix()), t.conID, t.kind, t.id, t.serial)
+	}
 
-		physDiskLines := strings.Split(string(out), "\n")
+	conIDs, _ := e.sumCol.Controllers()
+	physStatuses := make(map[string][]string)
+	for _, physCol := range physCols {
+		physStatuses[physCol.ConID] = append(physStatuses[physCol.ConID], physCol.Status())
+	}
+	logStatuses := make(map[string][]string)
+	for _, logCol := range logCols {
+		logStatuses[logCol.ConID] = append(logStatuses[logCol.ConID], logCol.Status())
+	}
+	for _, conID := range conIDs {
+		collectStateCounts(ch, physDiskStateCountDesc, collector.PhysDiskStates, physStatuses[conID], conID)
+		collectStateCounts(ch, logDiskStateCountDesc, collector.LogDiskStates, logStatuses[conID], conID)
+	}
 
-		// For the first time through, e.cachedPhysDiskLines will be empty,
-		// so we need to populate it
-		if i >= len(e.cachedPhysDiskLines) {
-			e.cachedPhysDiskLines = append(e.cachedPhysDiskLines, make([]string, 0))
-		}
+	if config := e.sumCol.Config(); config != nil {
+		collectRedundancy(ch, config, physCols, logCols)
+	}
 
-		if !reflect.DeepEqual(e.cachedPhysDiskLines[i], physDiskLines) {
-			e.cachedPhysDiskLines[i] = physDiskLines
+	collector.CollectInstrumentation(ch)
+	e.health.collect(ch)
 
-			physDiskN := 0
-			for _, physDiskLine := range physDiskLines {
-				if strings.TrimSpace(physDiskLine) == "" {
-					continue
-				}
+	e.collectCacheAge(ch, e.sumCol.LastRefresh(), "ssacli_sum", "", "")
+	e.collectCacheAge(ch, e.topoCol.LastRefresh(), "ssacli_topology", "", "")
+	for _, arrCol := range arrCols {
+		e.collectCacheAge(ch, arrCol.LastRefresh(), "ssacli_array", arrCol.ConID, "")
+	}
+	for _, encCol := range encCols {
+		e.collectCacheAge(ch, encCol.LastRefresh(), "ssacli_enclosure", encCol.ConID, "")
+	}
+	for _, physCol := range physCols {
+		e.collectCacheAge(ch, physCol.LastRefresh(), "ssacli_physical_disk", physCol.ConID, physCol.DiskID)
+	}
+	for _, logCol := range logCols {
+		e.collectCacheAge(ch, logCol.LastRefresh(), "ssacli_logical_disk", logCol.ConID, logCol.DiskID)
+	}
+	for _, smrtCol := range smrtCols {
+		e.collectCacheAge(ch, smrtCol.LastRefresh(), "smartctl", smrtCol.ConID, strconv.Itoa(smrtCol.DiskN))
+	}
+}
+
+// collectCacheAge reports how long ago a collector was last refreshed,
+// nothing is reported for collectors that were never refreshed
+func (e *Exporter) collectCacheAge(ch chan<- prometheus.Metric, lastRefresh time.Time, name, conID, id string) {
+	if lastRefresh.IsZero() {
+		return
+	}
 
-				physDiskFields := strings.Fields(physDiskLine)
-				physDisk := physDiskFields[1]
+	ch <- prometheus.MustNewConstMetric(
+		cacheAgeDesc,
+		prometheus.GaugeValue,
+		time.Since(lastRefresh).Seconds(),
+		name,
+		conID,
+		id,
+	)
+}
 
-				if !physDiskCollectorExists(e.physCols, physDisk, conID) {
-					e.physCols = append(e.physCols, *collector.NewSsacliPhysDiskCollector(e.logger, physDisk, conID, e.ssacliPath, e.sudoPath))
-				}
+// collectStateCounts reports how many of the statuses are in each state,
+// every known state being reported even when none is in it
+func collectStateCounts(ch chan<- prometheus.Metric, desc *prometheus.Desc, known []string, statuses []string, conID string) {
+	states := known
+	counts := make(map[string]int)
+	for _, status := range statuses {
+		states = collector.States(states, status)
+		counts[parser.SsacliState(status)]++
+	}
+
+	for _, state := range states {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(counts[state]), conID, state)
+	}
+}
 
-				if !smartCollectorExists(e.smrtCols, conID, conDev, physDiskN) {
-					e.smrtCols = append(e.smrtCols, *collector.NewSmartctlDiskCollector(e.logger, conID, conDev, physDiskN, e.smartctlPath, e.sudoPath))
+// refreshDetail runs `ssacli ctrl all show config detail`, which describes
+// every controller with its arrays, logical and physical drives in a single
+// invocation, and updates the collectors of the drives it reports. It also
+// runs lsscsi to find the sg device of each controller; when only lsscsi
+// fails the ssacli data is still updated, with the devices previously
+// known.
+func (e *Exporter) refreshDetail(ctx context.Context) {
+	configErr := e.sumCol.Refresh(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+	e.health.source("ssacli_config", configErr)
+
+	devErr := e.sumCol.RefreshDevices(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+	e.health.source("lsscsi", devErr)
+
+	// The collectors keep reporting the previous configuration
+	config := e.sumCol.Config()
+	if configErr != nil || config == nil {
+		return
+	}
+	e.topoCol.Update(config)
+	conIDs, conDevs := e.sumCol.Controllers()
+
+	e.mu.Lock()
+	if !reflect.DeepEqual(e.conIDs, conIDs) || !reflect.DeepEqual(e.conDevs, conDevs) {
+		// The collectors of controllers that are gone are removed by
+		// reconcile, only the probing has to start over
+		e.probed = make(map[string]int)
+
+		e.conIDs = conIDs
+		e.conDevs = conDevs
+		e.health.forget(conIDs)
+	}
+	physCols := slices.Clone(e.physCols)
+	logCols := slices.Clone(e.logCols)
+	smrtCols := slices.Clone(e.smrtCols)
+	arrCols := slices.Clone(e.arrCols)
+	encCols := slices.Clone(e.encCols)
+	e.mu.Unlock()
+
+	newArrCols := e.refreshArrays(ctx, config, arrCols)
+	newEncCols := e.refreshEnclosures(ctx, config, encCols)
+
+	newPhysCols := make([]*collector.SsacliPhysDiskCollector, 0)
+	newLogCols := make([]*collector.SsacliLogDiskCollector, 0)
+	newSmrtCols := make([]*collector.SmartctlDiskCollector, 0)
+
+	for _, con := range config.Controllers {
+		conID := con.SlotID
+		i := slices.Index(conIDs, conID)
+
+		physDisks := con.PhysDisks()
+		for _, physDisk := range physDisks {
+			physCol := findPhysDiskCollector(physCols, physDisk.ID, conID)
+			if physCol == nil {
+				physCol = collector.NewSsacliPhysDiskCollector(e.logger, physDisk.ID, conID)
+				physCols = append(physCols, physCol)
+				newPhysCols = append(newPhysCols, physCol)
+			}
+			physCol.Update(physDisk)
+		}
+
+		// The cciss indexes used by smartctl are assumed to be dense at
+		// first, gaps are probed for below once the disks were matched
+		if i >= len(conDevs) {
+			level.Warn(e.logger).Log("msg", "Exporter: No sg device known for controller, skipping smartctl", "conId", conID)
+		} else {
+			for diskN := range physDisks {
+				if !smartCollectorExists(smrtCols, conDevs[i], conID, diskN) && !smartCollectorExists(newSmrtCols, conDevs[i], conID, diskN) {
+					smrtCol := collector.NewSmartctlDiskCollector(e.logger, e.runner, conID, conDevs[i], diskN, e.smartctlPath)
+					newSmrtCols = append(newSmrtCols, smrtCol)
 				}
+			}
+		}
 
-				physDiskN++
+		for _, logDisk := range con.LogDisks() {
+			logCol := findLogDiskCollector(logCols, logDisk.ID, conID)
+			if logCol == nil {
+				logCol = collector.NewSsacliLogDiskCollector(e.logger, logDisk.ID, conID)
+				logCols = append(logCols, logCol)
+				newLogCols = append(newLogCols, logCol)
 			}
+			logCol.Update(logDisk)
+		}
+	}
+
+	// New smartctl collectors are refreshed right away and only then
+	// published, so that they do not wait for the next smartctl refresh.
+	e.refreshSmartctlCollectors(ctx, newSmrtCols)
+	newSmrtCols = append(newSmrtCols, e.probeSmartctl(ctx, config, conIDs, conDevs, append(smrtCols, newSmrtCols...))...)
+
+	e.mu.Lock()
+	e.arrCols = append(e.arrCols, newArrCols...)
+	e.encCols = append(e.encCols, newEncCols...)
+	e.physCols = append(e.physCols, newPhysCols...)
+	e.logCols = append(e.logCols, newLogCols...)
+	e.smrtCols = append(e.smrtCols, newSmrtCols...)
+	e.reconcile(config, conIDs, conDevs, time.Now())
+	for _, arrCol := range e.arrCols {
+		arrPhysCols := make([]*collector.SsacliPhysDiskCollector, 0)
+		for _, physCol := range e.physCols {
+			if physCol.ConID == arrCol.ConID {
+				arrPhysCols = append(arrPhysCols, physCol)
+			}
+		}
+		arrCol.SetPhysDiskCollectors(arrPhysCols)
+	}
+	e.mu.Unlock()
+
+	e.matchSmartctl()
+}
+
+// refreshArrays runs `ssacli ctrl slot=N array all show detail` for every
+// controller of the configuration, creating the collectors of the
+// controllers that have none. ssacli fails for controllers without arrays,
+// their collectors are cleared instead. The new collectors are returned.
+func (e *Exporter) refreshArrays(ctx context.Context, config *parser.SsacliConfig, arrCols []*collector.SsacliArrayCollector) []*collector.SsacliArrayCollector {
+	newArrCols := make([]*collector.SsacliArrayCollector, 0)
+	errs := make([]error, 0)
+
+	for _, con := range config.Controllers {
+		arrCol := findArrayCollector(arrCols, con.SlotID)
+		if arrCol == nil {
+			arrCol = collector.NewSsacliArrayCollector(e.logger, e.runner, con.SlotID, e.ssacliPath)
+			newArrCols = append(newArrCols, arrCol)
 		}
+		arrCol.SetTopology(con.Arrays)
 
-		// Export logic raid status
-		level.Info(e.logger).Log("msg", "Exporter: Invoking ssacli binary", "ssacliPath", e.ssacliPath)
-		out, err = exec.Command(e.sudoPath, e.ssacliPath, "ctrl", "slot="+conID, "ld", "all", "show", "status").CombinedOutput()
-		level.Debug(e.logger).Log("msg", "Exporter: ssacli ctrl slot=N ld all show status", "conId", conID, "out", out)
+		if len(con.Arrays) == 0 {
+			arrCol.Clear()
+			e.health.controller(con.SlotID, "ssacli_array", nil)
+			continue
+		}
 
+		err := arrCol.Refresh(ctx)
+		if ctx.Err() != nil {
+			return newArrCols
+		}
+		e.health.controller(con.SlotID, "ssacli_array", err)
 		if err != nil {
-			level.Error(e.logger).Log("msg", "Failed collecting metric", "out", out, "err", err)
-			return
+			errs = append(errs, err)
 		}
+	}
+	e.health.source("ssacli_array", errors.Join(errs...))
+
+	return newArrCols
+}
+
+// refreshEnclosures runs `ssacli ctrl slot=N enclosure all show detail` for
+// every controller of the configuration, creating the collectors of the
+// controllers that have none. ssacli fails for controllers whose
+// configuration lists no drive cage or enclosure, their collectors are
+// cleared instead. The new collectors are returned.
+func (e *Exporter) refreshEnclosures(ctx context.Context, config *parser.SsacliConfig, encCols []*collector.SsacliEnclosureCollector) []*collector.SsacliEnclosureCollector {
+	newEncCols := make([]*collector.SsacliEnclosureCollector, 0)
+	errs := make([]error, 0)
+
+	for _, con := range config.Controllers {
+		encCol := findEnclosureCollector(encCols, con.SlotID)
+		if encCol == nil {
+			encCol = collector.NewSsacliEnclosureCollector(e.logger, e.runner, con.SlotID, e.ssacliPath)
+			newEncCols = append(newEncCols, encCol)
+		}
+		encCol.SetTopology(con.PhysDisks())
+
+		if !con.HasEnclosures() {
+			encCol.Clear()
+			e.health.controller(con.SlotID, "ssacli_enclosure", nil)
+			continue
+		}
+
+		err := encCol.Refresh(ctx)
+		if ctx.Err() != nil {
+			return newEncCols
+		}
+		e.health.controller(con.SlotID, "ssacli_enclosure", err)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	e.health.source("ssacli_enclosure", errors.Join(errs...))
+
+	return newEncCols
+}
 
-		// For the first time through, e.cachedLogDiskLines will be empty,
-		// so we need to populate it
-		if i >= len(e.cachedLogDiskLines) {
-			e.cachedLogDiskLines = append(e.cachedLogDiskLines, make([]string, 0))
+// probeSmartctl looks for the disks of the controllers whose physical drives
+// are not all matched with a smartctl disk, by running smartctl for the
+// cciss indexes following the highest one known, up to ccissProbeExtra past
+// the number of physical drives. The indexes may not be dense, e.g. when a
+// bay is empty. Collectors of the indexes reporting a disk are returned.
+func (e *Exporter) probeSmartctl(ctx context.Context, config *parser.SsacliConfig, conIDs, conDevs []string, smrtCols []*collector.SmartctlDiskCollector) []*collector.SmartctlDiskCollector {
+	probed := make([]*collector.SmartctlDiskCollector, 0)
+
+	for _, con := range config.Controllers {
+		i := slices.Index(conIDs, con.SlotID)
+		if i < 0 || i >= len(conDevs) {
+			continue
 		}
+		conCols := filterSmartCollectors(smrtCols, conDevs[i], con.SlotID)
+
+		unmatched := make([]parser.SsacliConfigPhysDisk, 0)
+		for _, physDisk := range con.PhysDisks() {
+			if findSmartCollector(conCols, physDisk) == nil {
+				unmatched = append(unmatched, physDisk)
+			}
+		}
+
+		next := len(conCols)
+		for _, smrtCol := range conCols {
+			next = max(next, smrtCol.DiskN+1)
+		}
+
+		e.mu.Lock()
+		next = max(next, e.probed[conDevs[i]])
+		e.mu.Unlock()
+
+		limit := len(con.PhysDisks()) + ccissProbeExtra
+		for ; len(unmatched) > 0 && next < limit && ctx.Err() == nil; next++ {
+			smrtCol := collector.NewSmartctlDiskCollector(e.logger, e.runner, con.SlotID, conDevs[i], next, e.smartctlPath)
+			smrtCol.Refresh(ctx)
+
+			if serial, _ := smrtCol.Identity(); serial == "" {
+				continue
+			}
+			probed = append(probed, smrtCol)
+
+			unmatched = slices.DeleteFunc(unmatched, func(physDisk parser.SsacliConfigPhysDisk) bool {
+				return matchesPhysDisk(smrtCol, physDisk)
+			})
+		}
+
+		// Indexes that were probed are not probed again until the
+		// controllers change
+		e.mu.Lock()
+		e.probed[conDevs[i]] = next
+		e.mu.Unlock()
+	}
+
+	return probed
+}
+
+// matchSmartctl matches every smartctl disk with the ssacli physical drive
+// with the same serial number or WWID, and records the disks of either
+// source that could not be matched.
+func (e *Exporter) matchSmartctl() {
+	config := e.sumCol.Config()
+	if config == nil {
+		return
+	}
+
+	e.mu.Lock()
+	physCols := slices.Clone(e.physCols)
+	smrtCols := slices.Clone(e.smrtCols)
+	e.mu.Unlock()
 
-		logDiskLines := strings.Split(string(out), "\n")
-		if reflect.DeepEqual(e.cachedLogDiskLines[i], logDiskLines) {
-			e.cachedLogDiskLines[i] = logDiskLines
+	unmatched := make([]unmatchedDisk, 0)
+
+	for _, con := range config.Controllers {
+		physDisks := con.PhysDisks()
+		matched := make(map[string]bool)
+
+		for _, smrtCol := range smrtCols {
+			if smrtCol.ConID != con.SlotID {
+				continue
+			}
 
-			for _, logDiskLine := range logDiskLines {
-				if strings.TrimSpace(logDiskLine) == "" {
-					continue
+			physDisk, ok := findPhysDisk(physDisks, smrtCol)
+			if !ok {
+				smrtCol.SetDrive("", "")
+				if serial, _ := smrtCol.Identity(); serial != "" {
+					level.Warn(e.logger).Log("msg", "Exporter: smartctl disk matches no ssacli physical drive", "conId", con.SlotID, "diskN", smrtCol.DiskN, "serial", serial)
+					unmatched = append(unmatched, unmatchedDisk{conID: con.SlotID, source: "smartctl", id: strconv.Itoa(smrtCol.DiskN)})
 				}
+				continue
+			}
+
+			smrtCol.SetDrive(physDisk.ID, physDisk.SsacliPhysDiskData.Bay)
+			matched[physDisk.ID] = true
 
-				logDiskFields := strings.Fields(logDiskLine)
-				logDisk := logDiskFields[1]
+			if physCol := findPhysDiskCollector(physCols, physDisk.ID, con.SlotID); physCol != nil {
+				physCol.SetSmartctlEndurance(smrtCol.EnduranceUsed())
+			}
+		}
 
-				if !logDiskCollectorExists(e.logCols, logDisk, conID) {
-					e.logCols = append(e.logCols, *collector.NewSsacliLogDiskCollector(e.logger, logDisk, conID, e.ssacliPath, e.sudoPath))
+		for _, physDisk := range physDisks {
+			if !matched[physDisk.ID] {
+				if physCol := findPhysDiskCollector(physCols, physDisk.ID, con.SlotID); physCol != nil {
+					physCol.SetSmartctlEndurance(0, false)
 				}
+				level.Warn(e.logger).Log("msg", "Exporter: ssacli physical drive matches no smartctl disk", "conId", con.SlotID, "diskID", physDisk.ID, "serial", physDisk.SsacliPhysDiskData.SN)
+				unmatched = append(unmatched, unmatchedDisk{conID: con.SlotID, source: "ssacli", id: physDisk.ID})
 			}
 		}
 	}
 
-	// Now collect metrics
-	for _, physCol := range e.physCols {
-		physCol.Collect(ch)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.unmatched = unmatched
+}
+
+// refreshSmartctl runs smartctl for every known disk.
+func (e *Exporter) refreshSmartctl(ctx context.Context) {
+	conIDs, conDevs := e.sumCol.Controllers()
+
+	e.mu.Lock()
+	smrtCols := slices.Clone(e.smrtCols)
+	e.mu.Unlock()
+
+	errs := e.refreshSmartctlCollectors(ctx, smrtCols)
+	if ctx.Err() != nil {
+		return
 	}
 
-	for _, smrtCol := range e.smrtCols {
-		smrtCol.Collect(ch)
+	// smartctl cannot be run for the controllers whose sg device is unknown
+	conErrs := make(map[string][]error)
+	for i, conID := range conIDs {
+		if i >= len(conDevs) {
+			conErrs[conID] = append(conErrs[conID], errNoDevice)
+		}
+	}
+	for k, smrtCol := range smrtCols {
+		if errs[k] != nil {
+			conErrs[smrtCol.ConID] = append(conErrs[smrtCol.ConID], errs[k])
+		}
 	}
+	for _, conID := range conIDs {
+		e.health.controller(conID, "smartctl", errors.Join(conErrs[conID]...))
+	}
+	e.health.source("smartctl", errors.Join(errs...))
 
-	for _, logCol := range e.logCols {
-		logCol.Collect(ch)
+	// A disk may have been swapped at the same cciss index
+	e.matchSmartctl()
+}
+
+// refreshSmartctlCollectors refreshes the given collectors with at most
+// smartctlWorkers smartctl invocations running at any time. The error of
+// each refresh is returned at the index of its collector.
+func (e *Exporter) refreshSmartctlCollectors(ctx context.Context, smrtCols []*collector.SmartctlDiskCollector) []error {
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, e.smartctlWorkers)
+	errs := make([]error, len(smrtCols))
+
+	for k, smrtCol := range smrtCols {
+		select {
+		case workers <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(k int, smrtCol *collector.SmartctlDiskCollector) {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			errs[k] = smrtCol.Refresh(ctx)
+		}(k, smrtCol)
+	}
+
+	wg.Wait()
+	return errs
+}
+
+// refreshStatus runs the cheap `show status` calls of every controller and
+// updates the status of the known drives. When a drive appears or
+// disappears the controller configuration is refreshed right away.
+func (e *Exporter) refreshStatus(ctx context.Context) {
+	conIDs, _ := e.sumCol.Controllers()
+
+	e.mu.Lock()
+	physCols := slices.Clone(e.physCols)
+	logCols := slices.Clone(e.logCols)
+	e.mu.Unlock()
+
+	changed := false
+	errs := make([]error, 0)
+	statusIDs := make([]string, 0)
+
+	for _, conID := range conIDs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		// A failing call leaves the statuses it reports unchanged and does
+		// not prevent the other call, nor those of the other controllers
+		physIDs, physChanged, physErr := e.refreshPhysDiskStatus(ctx, conID, physCols)
+		logIDs, logChanged, logErr := e.refreshLogDiskStatus(ctx, conID, logCols)
+		if ctx.Err() != nil {
+			return
+		}
+		statusIDs = append(statusIDs, conID+" pd "+strings.Join(physIDs, ","), conID+" ld "+strings.Join(logIDs, ","))
+
+		err := errors.Join(physErr, logErr)
+		e.health.controller(conID, "ssacli_status", err)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		changed = changed || physChanged || logChanged
+	}
+	e.health.source("ssacli_status", errors.Join(errs...))
+
+	if ctx.Err() != nil {
+		return
+	}
+	if !changed {
+		e.mu.Lock()
+		e.forcedStatusIDs = ""
+		e.mu.Unlock()
+		return
+	}
+
+	// The configuration may keep disagreeing with the status calls, e.g.
+	// when a drive is listed by one and not the other, it is then only
+	// refreshed on its own interval
+	ids := strings.Join(statusIDs, "\n")
+	e.mu.Lock()
+	force := ids != e.forcedStatusIDs
+	e.forcedStatusIDs = ids
+	e.mu.Unlock()
+
+	if !force {
+		level.Debug(e.logger).Log("msg", "Exporter: Drives still differ from the controller configuration, already refreshed for them")
+		return
+	}
+	level.Info(e.logger).Log("msg", "Exporter: Drives changed, refreshing controller configuration")
+	e.source("detail").run(ctx)
+}
+
+// refreshPhysDiskStatus runs `ssacli ctrl slot=N pd all show status` and
+// updates the status of the physical drives of the controller. It returns
+// the IDs of the drives reported and whether they differ from the known
+// ones.
+func (e *Exporter) refreshPhysDiskStatus(ctx context.Context, conID string, physCols []*collector.SsacliPhysDiskCollector) ([]string, bool, error) {
+	level.Info(e.logger).Log("msg", "Exporter: Invoking ssacli binary", "ssacliPath", e.ssacliPath)
+	out, err := e.runner.Run(ctx, e.ssacliPath, "ctrl", "slot="+conID, "pd", "all", "show", "status")
+	level.Debug(e.logger).Log("msg", "Exporter: ssacli ctrl slot=N pd all show status", "conId", conID, "out", out)
+
+	if err != nil {
+		level.Error(e.logger).Log("msg", "Failed collecting metric", "conId", conID, "out", out, "err", err)
+		return nil, false, err
+	}
+
+	changed := false
+	ids := make([]string, 0)
+	physDisks := parser.ParseSsacliStatus(string(out))
+	for _, physDisk := range physDisks {
+		ids = append(ids, physDisk.ID)
+		physCol := findPhysDiskCollector(physCols, physDisk.ID, conID)
+		if physCol == nil {
+			changed = true
+			continue
+		}
+		physCol.SetStatus(physDisk.Status)
+	}
+	if len(physDisks) != countPhysDiskCollectors(physCols, conID) {
+		changed = true
 	}
 
+	return ids, changed, nil
 }
 
-func physDiskCollectorExists(s []collector.SsacliPhysDiskCollector, diskID string, conID string) bool {
+// refreshLogDiskStatus runs `ssacli ctrl slot=N ld all show status` and
+// updates the status of the logical drives of the controller. It returns
+// the IDs of the drives reported and whether they differ from the known
+// ones.
+func (e *Exporter) refreshLogDiskStatus(ctx context.Context, conID string, logCols []*collector.SsacliLogDiskCollector) ([]string, bool, error) {
+	level.Info(e.logger).Log("msg", "Exporter: Invoking ssacli binary", "ssacliPath", e.ssacliPath)
+	out, err := e.runner.Run(ctx, e.ssacliPath, "ctrl", "slot="+conID, "ld", "all", "show", "status")
+	level.Debug(e.logger).Log("msg", "Exporter: ssacli ctrl slot=N ld all show status", "conId", conID, "out", out)
+
+	if err != nil {
+		level.Error(e.logger).Log("msg", "Failed collecting metric", "conId", conID, "out", out, "err", err)
+		return nil, false, err
+	}
+
+	changed := false
+	ids := make([]string, 0)
+	logDisks := parser.ParseSsacliStatus(string(out))
+	for _, logDisk := range logDisks {
+		ids = append(ids, logDisk.ID)
+		logCol := findLogDiskCollector(logCols, logDisk.ID, conID)
+		if logCol == nil {
+			changed = true
+			continue
+		}
+		logCol.SetStatus(logDisk.Status)
+	}
+	if len(logDisks) != countLogDiskCollectors(logCols, conID) {
+		changed = true
+	}
+
+	return ids, changed, nil
+}
+
+// source returns the data source with the given name
+func (e *Exporter) source(name string) *source {
+	for _, src := range e.sources {
+		if src.name == name {
+			return src
+		}
+	}
+	return nil
+}
+
+func findPhysDiskCollector(s []*collector.SsacliPhysDiskCollector, diskID string, conID string) *collector.SsacliPhysDiskCollector {
 	for _, a := range s {
 		if a.DiskID == diskID && a.ConID == conID {
-			return true
+			return a
 		}
 	}
-	return false
+	return nil
 }
 
-func logDiskCollectorExists(s []collector.SsacliLogDiskCollector, diskID string, conID string) bool {
+func findArrayCollector(s []*collector.SsacliArrayCollector, conID string) *collector.SsacliArrayCollector {
+	for _, a := range s {
+		if a.ConID == conID {
+			return a
+		}
+	}
+	return nil
+}
+
+func findEnclosureCollector(s []*collector.SsacliEnclosureCollector, conID string) *collector.SsacliEnclosureCollector {
+	for _, a := range s {
+		if a.ConID == conID {
+			return a
+		}
+	}
+	return nil
+}
+
+func findLogDiskCollector(s []*collector.SsacliLogDiskCollector, diskID string, conID string) *collector.SsacliLogDiskCollector {
 	for _, a := range s {
 		if a.DiskID == diskID && a.ConID == conID {
-			return true
+			return a
 		}
 	}
-	return false
+	return nil
+}
+
+func countPhysDiskCollectors(s []*collector.SsacliPhysDiskCollector, conID string) int {
+	n := 0
+	for _, a := range s {
+		if a.ConID == conID {
+			n++
+		}
+	}
+	return n
+}
+
+func countLogDiskCollectors(s []*collector.SsacliLogDiskCollector, conID string) int {
+	n := 0
+	for _, a := range s {
+		if a.ConID == conID {
+			n++
+		}
+	}
+	return n
+}
+
+func filterSmartCollectors(s []*collector.SmartctlDiskCollector, conDev string, conID string) []*collector.SmartctlDiskCollector {
+	filtered := make([]*collector.SmartctlDiskCollector, 0)
+	for _, a := range s {
+		if a.ConDev == conDev && a.ConID == conID {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered
+}
+
+func findSmartCollector(s []*collector.SmartctlDiskCollector, physDisk parser.SsacliConfigPhysDisk) *collector.SmartctlDiskCollector {
+	for _, a := range s {
+		if matchesPhysDisk(a, physDisk) {
+			return a
+		}
+	}
+	return nil
+}
+
+func findPhysDisk(s []parser.SsacliConfigPhysDisk, smrtCol *collector.SmartctlDiskCollector) (parser.SsacliConfigPhysDisk, bool) {
+	for _, a := range s {
+		if matchesPhysDisk(smrtCol, a) {
+			return a, true
+		}
+	}
+	return parser.SsacliConfigPhysDisk{}, false
+}
+
+// matchesPhysDisk reports whether the smartctl disk is the ssacli physical
+// drive, comparing serial numbers and then WWIDs
+func matchesPhysDisk(smrtCol *collector.SmartctlDiskCollector, physDisk parser.SsacliConfigPhysDisk) bool {
+	serial, wwn := smrtCol.Identity()
+
+	sn := strings.TrimSpace(physDisk.SsacliPhysDiskData.SN)
+	if serial != "" && sn != "" && strings.EqualFold(serial, sn) {
+		return true
+	}
+
+	wwid := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(physDisk.SsacliPhysDiskData.WWID)), "0X")
+	return wwn != "" && wwid != "" && wwn == wwid
 }
 
-func smartCollectorExists(s []collector.SmartctlDiskCollector, conDev string, conID string, diskN int) bool {
+func smartCollectorExists(s []*collector.SmartctlDiskCollector, conDev string, conID string, diskN int) bool {
 	for _, a := range s {
 		if a.ConDev == conDev && a.ConID == conID && a.DiskN == diskN {
 			return true
@@ -0,0 +1,421 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/john-craig/smartctl_ssacli_exporter/collector"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collectorFunc turns a collect function into a prometheus.Collector
+type collectorFunc func(ch chan<- prometheus.Metric)
+
+func (f collectorFunc) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(f, ch)
+}
+
+func (f collectorFunc) Collect(ch chan<- prometheus.Metric) {
+	f(ch)
+}
+
+// gather registers c with a new registry and returns the value of every
+// gauge, counter and untyped series it reports, keyed by name and labels,
+// e.g. `ssacli_array_status{arrayID="A",conID="0",state="OK"}`. Gathering
+// fails the test on inconsistent metrics such as duplicate series.
+func gather(t *testing.T, c prometheus.Collector) map[string]float64 {
+	t.Helper()
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+
+	got := make(map[string]float64)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := make([]string, 0)
+			for _, label := range metric.GetLabel() {
+				labels = append(labels, label.GetName()+"="+strconv.Quote(label.GetValue()))
+			}
+			key := family.GetName() + "{" + strings.Join(labels, ",") + "}"
+
+			switch {
+			case metric.Gauge != nil:
+				got[key] = metric.GetGauge().GetValue()
+			case metric.Counter != nil:
+				got[key] = metric.GetCounter().GetValue()
+			case metric.Untyped != nil:
+				got[key] = metric.GetUntyped().GetValue()
+			}
+		}
+	}
+	return got
+}
+
+// configMirrored is a controller with a RAID 1+0 array, whose logical drive
+// lists its members again under its mirror groups, and a spare
+const configMirrored = `
+Smart Array P440ar in Slot 0 (Embedded)
+   Slot: 0
+   Controller Status: OK
+
+   Internal Drive Cage at Port 1I, Box 1, OK
+      Drive Bays: 8
+      Port: 1I
+      Box: 1
+
+   Array: A
+      Interface Type: SAS
+      Status: OK
+
+      Logical Drive: 1
+         Size: 1.2 TB
+         Fault Tolerance: 1+0
+         Status: OK
+         Unique Identifier: 600508B1001C5EF0A1B2C3D4E5F60718
+         Mirror Group 1:
+            physicaldrive 1I:1:1 (port 1I:box 1:bay 1, SAS HDD, 600 GB, OK)
+            physicaldrive 1I:1:2 (port 1I:box 1:bay 2, SAS HDD, 600 GB, OK)
+         Mirror Group 2:
+            physicaldrive 1I:1:3 (port 1I:box 1:bay 3, SAS HDD, 600 GB, OK)
+            physicaldrive 1I:1:4 (port 1I:box 1:bay 4, SAS HDD, 600 GB, OK)
+         Drive Type: Data
+
+      physicaldrive 1I:1:1
+         Status: OK
+         Drive Type: Data Drive
+         Interface Type: SAS
+         Size: 600 GB
+         Serial Number: SN1
+
+      physicaldrive 1I:1:2
+         Status: OK
+         Drive Type: Data Drive
+         Interface Type: SAS
+         Size: 600 GB
+         Serial Number: SN2
+
+      physicaldrive 1I:1:3
+         Status: OK
+         Drive Type: Data Drive
+         Interface Type: SAS
+         Size: 600 GB
+         Serial Number: SN3
+
+      physicaldrive 1I:1:4
+         Status: OK
+         Drive Type: Data Drive
+         Interface Type: SAS
+         Size: 600 GB
+         Serial Number: SN4
+
+      physicaldrive 1I:1:5
+         Status: OK
+         Drive Type: Spare Drive
+         Interface Type: SAS
+         Size: 600 GB
+         Serial Number: SN5
+`
+
+const (
+	lsscsiOutput = `[0:0:0:0]    storage HP       P440ar           6.60  -          /dev/sg0
+`
+	arrayOutput = `
+Smart Array P440ar in Slot 0 (Embedded)
+
+   Array: A
+      Interface Type: SAS
+      Unused Space: 0 MB (0.00%)
+      Used Space: 2.4 TB (100.00%)
+      Status: OK
+      Array Type: Data
+`
+	enclosureOutput = `
+Smart Array P440ar in Slot 0 (Embedded)
+
+   Internal Drive Cage at Port 1I, Box 1, OK
+
+      Fan Status: OK
+      Drive Bays: 8
+      Port: 1I
+      Box: 1
+`
+	pdStatusOutput = `
+   physicaldrive 1I:1:1 (port 1I:box 1:bay 1, 600 GB): OK
+   physicaldrive 1I:1:2 (port 1I:box 1:bay 2, 600 GB): Failed
+   physicaldrive 1I:1:3 (port 1I:box 1:bay 3, 600 GB): OK
+   physicaldrive 1I:1:4 (port 1I:box 1:bay 4, 600 GB): OK
+   physicaldrive 1I:1:5 (port 1I:box 1:bay 5, 600 GB): OK
+`
+	ldStatusOutput = `
+   logicaldrive 1 (1.2 TB, RAID 1+0): Interim Recovery Mode
+`
+)
+
+// newFakeRunner returns a runner with canned output for every ssacli and
+// lsscsi call of a refresh. smartctl has none, every disk is unmatched
+func newFakeRunner() *collector.FakeRunner {
+	runner := collector.NewFakeRunner()
+	runner.Set([]byte(configMirrored), nil, "ssacli", "ctrl", "all", "show", "config", "detail")
+	runner.Set([]byte(lsscsiOutput), nil, "lsscsi", "-g")
+	runner.Set([]byte(arrayOutput), nil, "ssacli", "ctrl", "slot=0", "array", "all", "show", "detail")
+	runner.Set([]byte(enclosureOutput), nil, "ssacli", "ctrl", "slot=0", "enclosure", "all", "show", "detail")
+	runner.Set([]byte(pdStatusOutput), nil, "ssacli", "ctrl", "slot=0", "pd", "all", "show", "status")
+	runner.Set([]byte(ldStatusOutput), nil, "ssacli", "ctrl", "slot=0", "ld", "all", "show", "status")
+	return runner
+}
+
+func newFakeExporter(runner collector.Runner) *Exporter {
+	return New(log.NewNopLogger(), runner, "smartctl", "ssacli", "lsscsi", Intervals{}, 1, time.Hour)
+}
+
+// assertMetrics fails the test for every series of want that got does not
+// report with the same value
+func assertMetrics(t *testing.T, got, want map[string]float64) {
+	t.Helper()
+	for key, value := range want {
+		if v, ok := got[key]; !ok || v != value {
+			t.Errorf("%s = %v (reported %v), want %v", key, v, ok, value)
+		}
+	}
+}
+
+func TestExporterTombstones(t *testing.T) {
+	runner := newFakeRunner()
+	e := newFakeExporter(runner)
+	e.Refresh(context.Background())
+
+	// The drive 1I:1:4 is removed from the configuration
+	config := strings.Replace(configMirrored, `
+      physicaldrive 1I:1:4
+         Status: OK
+         Drive Type: Data Drive
+         Interface Type: SAS
+         Size: 600 GB
+         Serial Number: SN4
+`, "", 1)
+	runner.Set([]byte(config), nil, "ssacli", "ctrl", "all", "show", "config", "detail")
+	e.Refresh(context.Background())
+
+	got := gather(t, e)
+	if _, ok := got[`ssacli_disappeared_drive_last_seen_timestamp_seconds{conID="0",diskID="1I:1:4",serial="SN4",type="physical_drive"}`]; !ok {
+		t.Errorf("no tombstone for the removed drive 1I:1:4")
+	}
+	if _, ok := got[`ssacli_physical_disk_status{conID="0",diskID="1I:1:4",state="OK"}`]; ok {
+		t.Errorf("the removed drive 1I:1:4 is still reported")
+	}
+
+	// Output without any controller removes nothing and reports the
+	// source down
+	runner.Set([]byte("\nWarning: no controller answered\n"), nil, "ssacli", "ctrl", "all", "show", "config", "detail")
+	e.Refresh(context.Background())
+
+	got = gather(t, e)
+	if v := got[`smartctl_ssacli_exporter_source_up{source="ssacli_config"}`]; v != 0 {
+		t.Errorf("ssacli_config source up = %v, want 0", v)
+	}
+	if _, ok := got[`ssacli_physical_disk_status{conID="0",diskID="1I:1:1",state="OK"}`]; !ok {
+		t.Errorf("the drive 1I:1:1 is no longer reported")
+	}
+	if _, ok := got[`ssacli_disappeared_drive_last_seen_timestamp_seconds{conID="0",diskID="1I:1:1",serial="SN1",type="physical_drive"}`]; ok {
+		t.Errorf("tombstone for the drive 1I:1:1")
+	}
+}
+
+// The enclosure detail does not list the drives, the bays are counted from
+// the drives of the configuration
+func TestExporterEnclosureOccupancy(t *testing.T) {
+	e := newFakeExporter(newFakeRunner())
+	e.Refresh(context.Background())
+
+	assertMetrics(t, gather(t, e), map[string]float64{
+		`ssacli_enclosure_occupied_bays{conID="0",enclosureID="1I:1"}`: 5,
+		`ssacli_enclosure_empty_bays{conID="0",enclosureID="1I:1"}`:    3,
+	})
+}
+
+func TestExporterControllerLabels(t *testing.T) {
+	e := newFakeExporter(newFakeRunner())
+	e.Refresh(context.Background())
+
+	assertMetrics(t, gather(t, e), map[string]float64{
+		`smartctl_ssacli_exporter_controller_up{conID="0",source="ssacli_status"}`:       1,
+		`smartctl_ssacli_exporter_controller_up{conID="0",source="ssacli_array"}`:        1,
+		`smartctl_ssacli_exporter_unmatched_disk{conID="0",id="1I:1:1",source="ssacli"}`: 1,
+	})
+}
+
+func TestExporterArrays(t *testing.T) {
+	e := newFakeExporter(newFakeRunner())
+	e.Refresh(context.Background())
+
+	got := gather(t, e)
+	assertMetrics(t, got, map[string]float64{
+		`ssacli_array_member_drives{arrayID="A",conID="0"}`:                      4,
+		`ssacli_array_spare_drives{arrayID="A",conID="0"}`:                       1,
+		`ssacli_array_spare_drive_usable{arrayID="A",conID="0",diskID="1I:1:5"}`: 1,
+		`ssacli_array_usable_spare{arrayID="A",conID="0"}`:                       1,
+	})
+	for key := range got {
+		if strings.HasPrefix(key, "ssacli_array_spare_drive_info{") {
+			t.Errorf("%s reported", key)
+		}
+	}
+}
+
+// A refresh runs the whole pipeline against the canned output of the fake
+// runner
+func TestExporterRefresh(t *testing.T) {
+	runner := newFakeRunner()
+	e := newFakeExporter(runner)
+	e.Refresh(context.Background())
+
+	assertMetrics(t, gather(t, e), map[string]float64{
+		`smartctl_ssacli_exporter_source_up{source="ssacli_config"}`:            1,
+		`smartctl_ssacli_exporter_source_up{source="lsscsi"}`:                   1,
+		`smartctl_ssacli_exporter_source_up{source="ssacli_status"}`:            1,
+		`smartctl_ssacli_exporter_source_up{source="smartctl"}`:                 0,
+		`ssacli_physical_disk_status{conID="0",diskID="1I:1:1",state="OK"}`:     1,
+		`ssacli_physical_disk_status{conID="0",diskID="1I:1:2",state="Failed"}`: 1,
+		`ssacli_logical_array_redundancy_remaining{conID="0",diskID="1"}`:       0,
+		`ssacli_controller_zero_redundancy_bytes{conID="0"}`:                    1.2e12,
+	})
+
+	calls := make(map[string]bool)
+	for _, call := range runner.Calls() {
+		calls[call] = true
+	}
+	for _, call := range []string{
+		"ssacli ctrl all show config detail",
+		"ssacli ctrl slot=0 pd all show status",
+		"ssacli ctrl slot=0 ld all show status",
+	} {
+		if !calls[call] {
+			t.Errorf("%q not run, calls = %q", call, runner.Calls())
+		}
+	}
+}
+
+// ssacli fails for controllers without arrays, the array call is not run
+// and the arrays previously reported are dropped
+func TestExporterNoArrays(t *testing.T) {
+	runner := newFakeRunner()
+	e := newFakeExporter(runner)
+	e.Refresh(context.Background())
+
+	runner.Set([]byte(`
+Smart Array P440ar in Slot 0 (Embedded)
+   Slot: 0
+   Controller Status: OK
+
+   Unassigned
+
+      physicaldrive 1I:1:1
+         Status: OK
+         Drive Type: Unassigned Drive
+         Interface Type: SAS
+         Size: 600 GB
+         Serial Number: SN1
+`), nil, "ssacli", "ctrl", "all", "show", "config", "detail")
+	runner.Set(nil, errors.New("exit status 1"), "ssacli", "ctrl", "slot=0", "array", "all", "show", "detail")
+	e.Refresh(context.Background())
+
+	got := gather(t, e)
+	assertMetrics(t, got, map[string]float64{
+		`smartctl_ssacli_exporter_source_up{source="ssacli_array"}`:               1,
+		`smartctl_ssacli_exporter_controller_up{conID="0",source="ssacli_array"}`: 1,
+	})
+	for key := range got {
+		if strings.HasPrefix(key, "ssacli_array_") {
+			t.Errorf("%s reported", key)
+		}
+	}
+}
+
+// The enclosure call is not run for controllers whose configuration lists
+// no drive cage, and the enclosures are dropped when it fails
+func TestExporterNoEnclosures(t *testing.T) {
+	runner := newFakeRunner()
+	e := newFakeExporter(runner)
+	e.Refresh(context.Background())
+
+	runner.Set(nil, errors.New("exit status 1"), "ssacli", "ctrl", "slot=0", "enclosure", "all", "show", "detail")
+	e.Refresh(context.Background())
+
+	got := gather(t, e)
+	if v := got[`smartctl_ssacli_exporter_controller_up{conID="0",source="ssacli_enclosure"}`]; v != 0 {
+		t.Errorf("ssacli_enclosure controller up = %v, want 0", v)
+	}
+	for key := range got {
+		if strings.HasPrefix(key, "ssacli_enclosure_") {
+			t.Errorf("%s reported after a failure", key)
+		}
+	}
+
+	config := strings.Replace(configMirrored, `
+   Internal Drive Cage at Port 1I, Box 1, OK
+      Drive Bays: 8
+      Port: 1I
+      Box: 1
+`, "", 1)
+	runner.Set([]byte(config), nil, "ssacli", "ctrl", "all", "show", "config", "detail")
+	calls := len(runner.Calls())
+	e.Refresh(context.Background())
+
+	for _, call := range runner.Calls()[calls:] {
+		if strings.Contains(call, "enclosure") {
+			t.Errorf("%q run without any drive cage", call)
+		}
+	}
+	assertMetrics(t, gather(t, e), map[string]float64{
+		`smartctl_ssacli_exporter_source_up{source="ssacli_enclosure"}`:               1,
+		`smartctl_ssacli_exporter_controller_up{conID="0",source="ssacli_enclosure"}`: 1,
+	})
+}
+
+// A drive listed by the status calls and not by the configuration forces a
+// single refresh of the configuration until the drives listed change
+func TestExporterStatusForcedRefresh(t *testing.T) {
+	runner := newFakeRunner()
+	e := newFakeExporter(runner)
+	e.Refresh(context.Background())
+
+	configCalls := func() int {
+		n := 0
+		for _, call := range runner.Calls() {
+			if call == "ssacli ctrl all show config detail" {
+				n++
+			}
+		}
+		return n
+	}
+
+	extra := pdStatusOutput + "   physicaldrive 1I:1:6 (port 1I:box 1:bay 6, 600 GB): OK\n"
+	runner.Set([]byte(extra), nil, "ssacli", "ctrl", "slot=0", "pd", "all", "show", "status")
+
+	before := configCalls()
+	for i := 0; i < 3; i++ {
+		e.source("status").run(context.Background())
+	}
+	if n := configCalls() - before; n != 1 {
+		t.Errorf("configuration refreshed %d times for the same drives, want 1", n)
+	}
+
+	extra += "   physicaldrive 1I:1:7 (port 1I:box 1:bay 7, 600 GB): OK\n"
+	runner.Set([]byte(extra), nil, "ssacli", "ctrl", "slot=0", "pd", "all", "show", "status")
+
+	before = configCalls()
+	e.source("status").run(context.Background())
+	e.source("status").run(context.Background())
+	if n := configCalls() - before; n != 1 {
+		t.Errorf("configuration refreshed %d times for other drives, want 1", n)
+	}
+}
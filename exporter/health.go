@@ -0,0 +1,125 @@
+package exporter
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	sourceUpDesc = prometheus.NewDesc(
+		"smartctl_ssacli_exporter_source_up",
+		"Whether every invocation of the last refresh of a source succeeded",
+		[]string{"source"},
+		nil,
+	)
+	sourceLastSuccessDesc = prometheus.NewDesc(
+		"smartctl_ssacli_exporter_source_last_success_timestamp_seconds",
+		"Time of the last refresh of a source where every invocation succeeded",
+		[]string{"source"},
+		nil,
+	)
+	controllerUpDesc = prometheus.NewDesc(
+		"smartctl_ssacli_exporter_controller_up",
+		"Whether every invocation of the last refresh of a source succeeded for a controller",
+		[]string{"conID", "source"},
+		nil,
+	)
+	controllerLastSuccessDesc = prometheus.NewDesc(
+		"smartctl_ssacli_exporter_controller_last_success_timestamp_seconds",
+		"Time of the last refresh of a source where every invocation succeeded for a controller",
+		[]string{"conID", "source"},
+		nil,
+	)
+)
+
+// outcome is the result of the last refresh of a source
+type outcome struct {
+	up          bool
+	lastSuccess time.Time
+}
+
+// health records the outcome of the last refresh of each source, overall
+// and per controller
+type health struct {
+	mu          sync.Mutex
+	sources     map[string]*outcome
+	controllers map[[2]string]*outcome
+}
+
+func newHealth() *health {
+	return &health{
+		sources:     make(map[string]*outcome),
+		controllers: make(map[[2]string]*outcome),
+	}
+}
+
+// source records the outcome of a refresh of the named source
+func (h *health) source(name string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	record(h.sources, name, err)
+}
+
+// controller records the outcome of a refresh of the named source for the
+// controller
+func (h *health) controller(conID, name string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	record(h.controllers, [2]string{conID, name}, err)
+}
+
+// forget drops the outcomes of the controllers that are not in conIDs
+func (h *health) forget(conIDs []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for key := range h.controllers {
+		if !slices.Contains(conIDs, key[0]) {
+			delete(h.controllers, key)
+		}
+	}
+}
+
+func record[K comparable](outcomes map[K]*outcome, key K, err error) {
+	o, ok := outcomes[key]
+	if !ok {
+		o = &outcome{}
+		outcomes[key] = o
+	}
+
+	o.up = err == nil
+	if o.up {
+		o.lastSuccess = time.Now()
+	}
+}
+
+func (h *health) collect(ch chan<- prometheus.Metric) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for name, o := range h.sources {
+		ch <- prometheus.MustNewConstMetric(sourceUpDesc, prometheus.GaugeValue, boolToFloat(o.up), name)
+		if !o.lastSuccess.IsZero() {
+			ch <- prometheus.MustNewConstMetric(sourceLastSuccessDesc, prometheus.GaugeValue, float64(o.lastSuccess.Unix()), name)
+		}
+	}
+
+	for key, o := range h.controllers {
+		ch <- prometheus.MustNewConstMetric(controllerUpDesc, prometheus.GaugeValue, boolToFloat(o.up), key[0], key[1])
+		if !o.lastSuccess.IsZero() {
+			ch <- prometheus.MustNewConstMetric(controllerLastSuccessDesc, prometheus.GaugeValue, float64(o.lastSuccess.Unix()), key[0], key[1])
+		}
+	}
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
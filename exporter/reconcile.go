@@ -0,0 +1,102 @@
+package exporter
+
+import (
+	"slices"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/john-craig/smartctl_ssacli_exporter/collector"
+	"github.com/john-craig/smartctl_ssacli_exporter/parser"
+)
+
+// tombstone records a drive that disappeared from the controller
+// configuration
+type tombstone struct {
+	conID    string
+	kind     string
+	id       string
+	serial   string
+	lastSeen time.Time
+}
+
+// reconcile removes the collectors of the physical and logical drives that
+// are no longer part of the controller configuration, leaving a tombstone
+// for each, the array and enclosure collectors of controllers that are
+// gone, and the smartctl collectors of controllers that are gone or of cciss
+// indexes where no disk is found anymore. Tombstones older than
+// tombstoneLifetime and those of drives that reappeared are dropped.
+//
+// It must be called with e.mu held.
+func (e *Exporter) reconcile(config *parser.SsacliConfig, conIDs, conDevs []string, now time.Time) {
+	physDisks := make(map[[2]string]bool)
+	logDisks := make(map[[2]string]bool)
+	physDiskCount := make(map[string]int)
+	for _, con := range config.Controllers {
+		for _, physDisk := range con.PhysDisks() {
+			physDisks[[2]string{con.SlotID, physDisk.ID}] = true
+			physDiskCount[con.SlotID]++
+		}
+		for _, logDisk := range con.LogDisks() {
+			logDisks[[2]string{con.SlotID, logDisk.ID}] = true
+		}
+	}
+
+	e.physCols = slices.DeleteFunc(e.physCols, func(physCol *collector.SsacliPhysDiskCollector) bool {
+		if physDisks[[2]string{physCol.ConID, physCol.DiskID}] {
+			return false
+		}
+		level.Warn(e.logger).Log("msg", "Exporter: Physical drive disappeared", "conId", physCol.ConID, "diskID", physCol.DiskID)
+		e.tombstones = append(e.tombstones, tombstone{
+			conID:    physCol.ConID,
+			kind:     "physical_drive",
+			id:       physCol.DiskID,
+			serial:   physCol.Serial(),
+			lastSeen: physCol.LastRefresh(),
+		})
+		return true
+	})
+
+	e.logCols = slices.DeleteFunc(e.logCols, func(logCol *collector.SsacliLogDiskCollector) bool {
+		if logDisks[[2]string{logCol.ConID, logCol.DiskID}] {
+			return false
+		}
+		level.Warn(e.logger).Log("msg", "Exporter: Logical drive disappeared", "conId", logCol.ConID, "diskID", logCol.DiskID)
+		e.tombstones = append(e.tombstones, tombstone{
+			conID:    logCol.ConID,
+			kind:     "logical_drive",
+			id:       logCol.DiskID,
+			serial:   logCol.UID(),
+			lastSeen: logCol.LastRefresh(),
+		})
+		return true
+	})
+
+	e.arrCols = slices.DeleteFunc(e.arrCols, func(arrCol *collector.SsacliArrayCollector) bool {
+		return !slices.Contains(conIDs, arrCol.ConID)
+	})
+	e.encCols = slices.DeleteFunc(e.encCols, func(encCol *collector.SsacliEnclosureCollector) bool {
+		return !slices.Contains(conIDs, encCol.ConID)
+	})
+
+	// The cciss indexes below the number of physical drives are always
+	// kept, the others only while a disk is found there
+	e.smrtCols = slices.DeleteFunc(e.smrtCols, func(smrtCol *collector.SmartctlDiskCollector) bool {
+		i := slices.Index(conIDs, smrtCol.ConID)
+		if i < 0 || i >= len(conDevs) || conDevs[i] != smrtCol.ConDev {
+			return true
+		}
+		return smrtCol.DiskN >= physDiskCount[smrtCol.ConID] && !smrtCol.Present()
+	})
+
+	e.tombstones = slices.DeleteFunc(e.tombstones, func(t tombstone) bool {
+		switch {
+		case now.Sub(t.lastSeen) > e.tombstoneLifetime:
+			return true
+		case t.kind == "physical_drive":
+			return physDisks[[2]string{t.conID, t.id}]
+		case t.kind == "logical_drive":
+			return logDisks[[2]string{t.conID, t.id}]
+		}
+		return false
+	})
+}
@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/john-craig/smartctl_ssacli_exporter/collector"
+	"github.com/john-craig/smartctl_ssacli_exporter/parser"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// healthyPhysDiskStates are the states of a physical drive that still holds
+// its share of the data of its array
+var healthyPhysDiskStates = []string{"OK", "Predictive Failure"}
+
+// degradedLogDiskStates are the states of a logical drive that has lost a
+// drive, in case the failed drive itself is no longer reported
+var degradedLogDiskStates = []string{"Interim Recovery Mode", "Ready for Rebuild", "Recovering"}
+
+// faultTolerance returns how many drive failures a logical drive with the
+// fault tolerance reported by ssacli, e.g. `5`, `1+0` or `6 (ADG)`, is
+// guaranteed to survive. ok is false for unknown fault tolerances.
+func faultTolerance(ft string) (int, bool) {
+	switch strings.ToUpper(strings.ReplaceAll(ft, " ", "")) {
+	case "0":
+		return 0, true
+	case "1", "1+0", "10", "5", "50":
+		return 1, true
+	case "1(ADM)", "1+0(ADM)", "10(ADM)", "ADM", "6", "6(ADG)", "ADG", "60":
+		return 2, true
+	}
+	return 0, false
+}
+
+// collectRedundancy reports how many more drive failures each logical drive
+// can survive, from its fault tolerance and the status of the data drives
+// of its array, and how many bytes of logical drives are left without
+// redundancy on each controller.
+func collectRedundancy(ch chan<- prometheus.Metric, config *parser.SsacliConfig, physCols []*collector.SsacliPhysDiskCollector, logCols []*collector.SsacliLogDiskCollector) {
+	for _, con := range config.Controllers {
+		atRisk := 0.0
+
+		for _, array := range con.Arrays {
+			failed := 0
+			for _, physDisk := range array.PhysDisks {
+				if strings.Contains(physDisk.SsacliPhysDiskData.DriveType, "Spare") {
+					continue
+				}
+				status := physDisk.SsacliPhysDiskData.Status
+				if physCol := findPhysDiskCollector(physCols, physDisk.ID, con.SlotID); physCol != nil {
+					status = physCol.Status()
+				}
+				if !slices.Contains(healthyPhysDiskStates, parser.SsacliState(status)) {
+					failed++
+				}
+			}
+
+			for _, logDisk := range array.LogDisks {
+				tolerance, ok := faultTolerance(logDisk.SsacliLogDiskData.FaultTolerance)
+				if !ok {
+					continue
+				}
+
+				status := logDisk.SsacliLogDiskData.Status
+				if logCol := findLogDiskCollector(logCols, logDisk.ID, con.SlotID); logCol != nil {
+					status = logCol.Status()
+				}
+
+				lost := failed
+				switch state := parser.SsacliState(status); {
+				case state == "Failed":
+					lost = tolerance
+				case lost == 0 && slices.Contains(degradedLogDiskStates, state):
+					lost = 1
+				}
+
+				remaining := max(tolerance-lost, 0)
+				ch <- prometheus.MustNewConstMetric(redundancyRemainingDesc, prometheus.GaugeValue, float64(remaining), con.SlotID, logDisk.ID)
+
+				if remaining == 0 && !logDisk.ParseErrs.Failed("Size") {
+					atRisk += logDisk.SsacliLogDiskData.SizeBytes
+				}
+			}
+		}
+
+		ch <- prometheus.MustNewConstMetric(zeroRedundancyBytesDesc, prometheus.GaugeValue, atRisk, con.SlotID)
+	}
+}
@@ -0,0 +1,134 @@
+package exporter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/john-craig/smartctl_ssacli_exporter/collector"
+	"github.com/john-craig/smartctl_ssacli_exporter/parser"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestFaultTolerance(t *testing.T) {
+	tests := []struct {
+		ft   string
+		want int
+		ok   bool
+	}{
+		{ft: "0", want: 0, ok: true},
+		{ft: "1", want: 1, ok: true},
+		{ft: "1+0", want: 1, ok: true},
+		{ft: "5", want: 1, ok: true},
+		{ft: "50", want: 1, ok: true},
+		{ft: "6", want: 2, ok: true},
+		{ft: "6 (ADG)", want: 2, ok: true},
+		{ft: "60", want: 2, ok: true},
+		{ft: "1 (ADM)", want: 2, ok: true},
+		{ft: "1+0 (adm)", want: 2, ok: true},
+		{ft: "", ok: false},
+		{ft: "7", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := faultTolerance(tt.ft)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("faultTolerance(%q) = %d, %v, want %d, %v", tt.ft, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// redundancyConfig returns a controller with a single array holding a 1 TB
+// logical drive and a data drive per status, followed by a failed spare
+func redundancyConfig(ft, ldStatus string, pdStatuses ...string) *parser.SsacliConfig {
+	array := parser.SsacliConfigArray{
+		ID: "A",
+		LogDisks: []parser.SsacliConfigLogDisk{{
+			ID: "1",
+			SsacliLogDiskData: parser.SsacliLogDiskData{
+				Status:         ldStatus,
+				FaultTolerance: ft,
+				SizeBytes:      1e12,
+			},
+		}},
+	}
+	for i, status := range pdStatuses {
+		array.PhysDisks = append(array.PhysDisks, parser.SsacliConfigPhysDisk{
+			ID:                 fmt.Sprintf("1I:1:%d", i+1),
+			SsacliPhysDiskData: parser.SsacliPhysDiskData{Status: status, DriveType: "Data Drive"},
+		})
+	}
+	array.PhysDisks = append(array.PhysDisks, parser.SsacliConfigPhysDisk{
+		ID:                 fmt.Sprintf("1I:1:%d", len(pdStatuses)+1),
+		SsacliPhysDiskData: parser.SsacliPhysDiskData{Status: "Failed", DriveType: "Spare Drive"},
+	})
+
+	return &parser.SsacliConfig{Controllers: []parser.SsacliConfigController{{
+		SlotID: "0",
+		Arrays: []parser.SsacliConfigArray{array},
+	}}}
+}
+
+func TestCollectRedundancy(t *testing.T) {
+	const (
+		remainingKey = `ssacli_logical_array_redundancy_remaining{conID="0",diskID="1"}`
+		atRiskKey    = `ssacli_controller_zero_redundancy_bytes{conID="0"}`
+	)
+
+	tests := []struct {
+		name      string
+		config    *parser.SsacliConfig
+		remaining float64
+		reported  bool
+		atRisk    float64
+	}{
+		{name: "RAID 5 healthy", config: redundancyConfig("5", "OK", "OK", "OK", "OK"), remaining: 1, reported: true},
+		{name: "RAID 5 failed drive", config: redundancyConfig("5", "Interim Recovery Mode", "OK", "Failed", "OK"), remaining: 0, reported: true, atRisk: 1e12},
+		{name: "RAID 5 degraded without failed drive", config: redundancyConfig("5", "Interim Recovery Mode", "OK", "OK"), remaining: 0, reported: true, atRisk: 1e12},
+		{name: "RAID 5 predictive failure", config: redundancyConfig("5", "OK", "OK", "Predictive Failure", "OK"), remaining: 1, reported: true},
+		{name: "RAID 6 failed drive", config: redundancyConfig("6 (ADG)", "Interim Recovery Mode", "OK", "Failed", "OK", "OK"), remaining: 1, reported: true},
+		{name: "RAID 6 two failed drives", config: redundancyConfig("6 (ADG)", "Interim Recovery Mode", "Failed", "Failed", "OK", "OK"), remaining: 0, reported: true, atRisk: 1e12},
+		{name: "RAID 6 failed logical drive", config: redundancyConfig("6 (ADG)", "Failed", "OK", "OK", "OK", "OK"), remaining: 0, reported: true, atRisk: 1e12},
+		{name: "RAID 1+0 rebuilding", config: redundancyConfig("1+0", "Recovering, 12% complete", "OK", "Rebuilding", "OK", "OK"), remaining: 0, reported: true, atRisk: 1e12},
+		{name: "RAID 0", config: redundancyConfig("0", "OK", "OK", "OK"), remaining: 0, reported: true, atRisk: 1e12},
+		{name: "unknown fault tolerance", config: redundancyConfig("42", "OK", "OK", "OK"), reported: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := gather(t, collectorFunc(func(ch chan<- prometheus.Metric) {
+				collectRedundancy(ch, tt.config, nil, nil)
+			}))
+
+			remaining, reported := got[remainingKey]
+			if reported != tt.reported || remaining != tt.remaining {
+				t.Errorf("%s = %v (reported %v), want %v (reported %v)", remainingKey, remaining, reported, tt.remaining, tt.reported)
+			}
+			if got[atRiskKey] != tt.atRisk {
+				t.Errorf("%s = %v, want %v", atRiskKey, got[atRiskKey], tt.atRisk)
+			}
+		})
+	}
+}
+
+// The status calls update the status of the drives between refreshes of
+// the configuration
+func TestCollectRedundancyCurrentStatus(t *testing.T) {
+	config := redundancyConfig("5", "OK", "OK", "OK", "OK")
+	con := config.Controllers[0]
+
+	physCols := make([]*collector.SsacliPhysDiskCollector, 0)
+	for _, physDisk := range con.PhysDisks() {
+		physCol := collector.NewSsacliPhysDiskCollector(log.NewNopLogger(), physDisk.ID, con.SlotID)
+		physCol.Update(physDisk)
+		physCols = append(physCols, physCol)
+	}
+	physCols[1].SetStatus("Failed")
+
+	got := gather(t, collectorFunc(func(ch chan<- prometheus.Metric) {
+		collectRedundancy(ch, config, physCols, nil)
+	}))
+	if v := got[`ssacli_logical_array_redundancy_remaining{conID="0",diskID="1"}`]; v != 0 {
+		t.Errorf("redundancy remaining = %v, want 0", v)
+	}
+}
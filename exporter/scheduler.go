@@ -0,0 +1,119 @@
+package exporter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// Intervals configures how often each data source is refreshed in the
+// background. A source with an interval of 0 is not refreshed in the
+// background but during every scrape instead.
+type Intervals struct {
+	// Status is the interval of the cheap `show status` calls updating the
+	// status of the physical and logical drives.
+	Status time.Duration
+	// Detail is the interval of the `show config detail` call describing
+	// the controllers with all their arrays and drives.
+	Detail time.Duration
+	// Smartctl is the interval of the smartctl calls of every disk.
+	Smartctl time.Duration
+}
+
+// DefaultIntervals are the intervals used when none are configured
+var DefaultIntervals = Intervals{
+	Status:   30 * time.Second,
+	Detail:   5 * time.Minute,
+	Smartctl: 5 * time.Minute,
+}
+
+// source is a data source refreshed as a whole
+type source struct {
+	name     string
+	interval time.Duration
+	refresh  func(context.Context)
+
+	// mu prevents concurrent refreshes of the same source, e.g. by the
+	// scheduler and a scrape
+	mu sync.Mutex
+}
+
+// run refreshes the source, waiting for any refresh already in progress
+func (s *source) run(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refresh(ctx)
+}
+
+// task is a refresh function run periodically by the scheduler
+type task struct {
+	name     string
+	interval time.Duration
+	run      func(context.Context)
+}
+
+// scheduler runs each task on its own interval, independently of the
+// others. Tools that cannot run concurrently are serialized by the runner.
+type scheduler struct {
+	logger log.Logger
+	tasks  []*task
+}
+
+func newScheduler(logger log.Logger) *scheduler {
+	return &scheduler{
+		logger: logger,
+		tasks:  make([]*task, 0),
+	}
+}
+
+// add registers a task. Tasks are first run in the order they were added.
+func (s *scheduler) add(name string, interval time.Duration, run func(context.Context)) {
+	s.tasks = append(s.tasks, &task{
+		name:     name,
+		interval: interval,
+		run:      run,
+	})
+}
+
+// start runs every task once, one after the other, then keeps running each
+// one whenever its interval has elapsed since it last completed, until ctx
+// is cancelled.
+func (s *scheduler) start(ctx context.Context) {
+	go func() {
+		for _, t := range s.tasks {
+			if ctx.Err() != nil {
+				return
+			}
+			s.runTask(ctx, t)
+		}
+
+		for _, t := range s.tasks {
+			go s.loop(ctx, t)
+		}
+	}()
+}
+
+func (s *scheduler) loop(ctx context.Context, t *task) {
+	for {
+		timer := time.NewTimer(t.interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.runTask(ctx, t)
+	}
+}
+
+func (s *scheduler) runTask(ctx context.Context, t *task) {
+	level.Debug(s.logger).Log("msg", "Scheduler: Running task", "task", t.name)
+	start := time.Now()
+	t.run(ctx)
+	level.Debug(s.logger).Log("msg", "Scheduler: Task completed", "task", t.name, "duration", time.Since(start))
+}
@@ -0,0 +1,116 @@
+package exporter
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	refreshTimedOutDesc = prometheus.NewDesc(
+		"smartctl_ssacli_exporter_refresh_timed_out",
+		"Whether the refresh of a source done during the scrape was cut short by the scrape timeout",
+		[]string{"source"},
+		nil,
+	)
+	scrapeDurationDesc = prometheus.NewDesc(
+		"smartctl_ssacli_exporter_scrape_duration_seconds",
+		"Duration of the scrape, including the refresh of the sources refreshed during scrapes",
+		nil,
+		nil,
+	)
+	unmatchedDiskDesc = prometheus.NewDesc(
+		"smartctl_ssacli_exporter_unmatched_disk",
+		"Disk reported by only one of smartctl and ssacli, whose serial number and WWID match no disk of the other. id is the cciss index for smartctl and the physical drive ID for ssacli",
+		[]string{"conID", "source", "id"},
+		nil,
+	)
+	tombstoneDesc = prometheus.NewDesc(
+		"ssacli_disappeared_drive_last_seen_timestamp_seconds",
+		"Time a drive was last seen in the controller configuration, reported for drives that disappeared from it. type is physical_drive or logical_drive, serial is the serial number of physical drives and the unique identifier of logical drives",
+		[]string{"conID", "type", "diskID", "serial"},
+		nil,
+	)
+	physDiskStateCountDesc = prometheus.NewDesc(
+		"ssacli_controller_physical_drives",
+		"Number of physical drives of a controller in each state",
+		[]string{"conID", "state"},
+		nil,
+	)
+	logDiskStateCountDesc = prometheus.NewDesc(
+		"ssacli_controller_logical_drives",
+		"Number of logical drives of a controller in each state",
+		[]string{"conID", "state"},
+		nil,
+	)
+	redundancyRemainingDesc = prometheus.NewDesc(
+		"ssacli_logical_array_redundancy_remaining",
+		"Number of further drive failures a logical drive is guaranteed to survive, from its fault tolerance and the status of the data drives of its array",
+		[]string{"conID", "diskID"},
+		nil,
+	)
+	zeroRedundancyBytesDesc = prometheus.NewDesc(
+		"ssacli_controller_zero_redundancy_bytes",
+		"Total size of the logical drives of a controller that would not survive another drive failure, RAID 0 ones included",
+		[]string{"conID"},
+		nil,
+	)
+	cacheAgeDesc = prometheus.NewDesc(
+		"smartctl_ssacli_exporter_cache_age_seconds",
+		"Time since the data reported by a collector was last refreshed",
+		[]string{"collector", "conID", "id"},
+		nil,
+	)
+)
+
+// scrapeCollector refreshes the on-demand sources of an Exporter under the
+// context of a single scrape
+type scrapeCollector struct {
+	exporter *Exporter
+	ctx      context.Context
+}
+
+var _ prometheus.Collector = &scrapeCollector{}
+
+// Describe sends no descriptors, which makes the collector unchecked
+func (c *scrapeCollector) Describe(ch chan<- *prometheus.Desc) {
+}
+
+// Collect refreshes the on-demand sources, then sends the metrics of every
+// source
+func (c *scrapeCollector) Collect(ch chan<- prometheus.Metric) {
+	start := time.Now()
+
+	for _, src := range c.exporter.sources {
+		if src.interval > 0 {
+			continue
+		}
+
+		if c.ctx.Err() == nil {
+			src.run(c.ctx)
+		}
+
+		timedOut := 0.0
+		if c.ctx.Err() != nil {
+			level.Warn(c.exporter.logger).Log("msg", "Scrape timed out before the source was refreshed, reporting previous data", "source", src.name, "err", c.ctx.Err())
+			timedOut = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			refreshTimedOutDesc,
+			prometheus.GaugeValue,
+			timedOut,
+			src.name,
+		)
+	}
+
+	c.exporter.Collect(ch)
+
+	ch <- prometheus.MustNewConstMetric(
+		scrapeDurationDesc,
+		prometheus.GaugeValue,
+		time.Since(start).Seconds(),
+	)
+}
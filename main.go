@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/go-kit/log/level"
+	"github.com/john-craig/smartctl_ssacli_exporter/collector"
 	"github.com/john-craig/smartctl_ssacli_exporter/exporter"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -17,9 +23,24 @@ var (
 
 	smartctlPath = flag.String("smartctl.path", "/usr/bin/smartctl", "Path to smartctl binary")
 	ssacliPath   = flag.String("ssacli.path", "/usr/bin/ssacli", "Path to ssacli binary")
-	lsscsiPath   = flag.String("lsscsi.path", "/usr/bin/lsscsci", "Path to lsscsi binary")
+	lsscsiPath   = flag.String("lsscsi.path", "/usr/bin/lsscsi", "Path to lsscsi binary")
 	sudoPath     = flag.String("sudo.path", "/usr/bin/sudo", "Path to sudo binary")
 
+	statusInterval   = flag.Duration("collect.interval.status", exporter.DefaultIntervals.Status, "Interval between ssacli show status calls updating the status of drives")
+	detailInterval   = flag.Duration("collect.interval.detail", exporter.DefaultIntervals.Detail, "Interval between ssacli show config detail calls discovering controllers and drives")
+	smartctlInterval = flag.Duration("collect.interval.smartctl", exporter.DefaultIntervals.Smartctl, "Interval between smartctl calls of each disk")
+
+	smartctlWorkers    = flag.Int("smartctl.workers", 4, "Maximum number of smartctl invocations running concurrently")
+	ssacliRetries      = flag.Int("ssacli.retries", 3, "Number of times an ssacli invocation failing on the controller lock is retried")
+	ssacliRetryBackoff = flag.Duration("ssacli.retry-backoff", time.Second, "Delay before the first retry of an ssacli invocation failing on the controller lock, doubled for each next retry")
+
+	commandTimeout      = flag.Duration("command.timeout", time.Minute, "Maximum duration of a single ssacli, smartctl or lsscsi invocation, 0 to disable")
+	scrapeTimeoutOffset = flag.Duration("web.scrape-timeout-offset", 500*time.Millisecond, "Offset subtracted from the X-Prometheus-Scrape-Timeout-Seconds header of scrapes")
+
+	tombstoneLifetime = flag.Duration("tombstone.lifetime", 24*time.Hour, "How long the last seen timestamp of a drive that disappeared is reported")
+
+	replayDir = flag.String("replay.dir", "", "Serve metrics from tool output recorded in this directory instead of running the tools")
+
 	logLevel = flag.String("log.level", "info", "Filter for log level, accepts: info, debug, info, warn, error")
 )
 
@@ -30,9 +51,70 @@ func main() {
 	logger := promlog.New(promlogConfig)
 	logger = level.NewFilter(logger, level.Allow(level.ParseDefault(*logLevel, level.InfoValue())))
 
-	prometheus.MustRegister(exporter.New(logger, *smartctlPath, *ssacliPath, *lsscsiPath, *sudoPath))
+	// ssacli fails when another instance holds the controller lock, so its
+	// invocations are queued and retried
+	var runner collector.Runner = collector.NewSerialRunner(
+		collector.NewInstrumentedRunner(collector.NewSudoRunner(*sudoPath, *commandTimeout, *lsscsiPath)),
+		*ssacliRetries,
+		*ssacliRetryBackoff,
+		*ssacliPath,
+	)
+	if *replayDir != "" {
+		replayRunner, err := collector.NewReplayRunner(*replayDir)
+		if err != nil {
+			level.Error(logger).Log("msg", "Cannot open replay directory", "dir", *replayDir, "err", err)
+			os.Exit(1)
+		}
+		level.Info(logger).Log("msg", "Replaying recorded tool output", "dir", *replayDir)
+		runner = collector.NewInstrumentedRunner(replayRunner)
+	}
 
-	http.Handle(*metricsPath, promhttp.Handler())
+	if flag.Arg(0) == "support-bundle" {
+		if err := runSupportBundle(logger, runner, flag.Args()[1:]); err != nil {
+			level.Error(logger).Log("msg", "Cannot write support bundle", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	e := exporter.New(logger, runner, *smartctlPath, *ssacliPath, *lsscsiPath, exporter.Intervals{
+		Status:   *statusInterval,
+		Detail:   *detailInterval,
+		Smartctl: *smartctlInterval,
+	}, *smartctlWorkers, *tombstoneLifetime)
+	e.Start(context.Background())
+
+	http.HandleFunc(*metricsPath, func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if header := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"); header != "" {
+			seconds, err := strconv.ParseFloat(header, 64)
+			if err == nil && seconds <= 0 {
+				err = errors.New("scrape timeout is not positive")
+			}
+			if err != nil {
+				level.Warn(logger).Log("msg", "Invalid scrape timeout header", "header", header, "err", err)
+			} else {
+				// An offset that would leave no time to the scrape is
+				// ignored, the deadline is then the scrape timeout itself
+				timeout := time.Duration(seconds * float64(time.Second))
+				if timeout > *scrapeTimeoutOffset {
+					timeout -= *scrapeTimeoutOffset
+				} else {
+					level.Warn(logger).Log("msg", "Scrape timeout offset not shorter than the scrape timeout, ignoring it", "timeout", timeout, "offset", *scrapeTimeoutOffset)
+				}
+
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+		}
+
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(e.ScrapeCollector(ctx))
+
+		gatherers := prometheus.Gatherers{prometheus.DefaultGatherer, registry}
+		promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	})
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, *metricsPath, http.StatusMovedPermanently)
 	})
@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError describes a value of the tool output that could not be parsed
+type ParseError struct {
+	// Func is the parser function that failed
+	Func string
+	// Field is the name of the field as printed by the tool
+	Field string
+	// Line is the 1-based line number of the value in the output
+	Line int
+	// Value is the raw value that could not be parsed
+	Value string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s: line %d: field %q: cannot parse %q: %v", e.Func, e.Line, e.Field, e.Value, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ParseErrors is returned by the parser functions alongside the data that
+// did parse. A field that failed is left at its zero value.
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Failed reports whether the field could not be parsed
+func (e ParseErrors) Failed(field string) bool {
+	for _, err := range e {
+		if err.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// errs returns nil when there is no error, so that the result of a parser
+// can be compared with nil
+func (e ParseErrors) errs() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// add records a failure of fn to parse value of field at line
+func (e *ParseErrors) add(fn, field string, line int, value string, err error) {
+	*e = append(*e, &ParseError{
+		Func:  fn,
+		Field: field,
+		Line:  line,
+		Value: value,
+		Err:   err,
+	})
+}
+
+// parseInt parses an integer value, recording a failure in e
+func (e *ParseErrors) parseInt(fn, field string, line int, value string) int64 {
+	i, err := toINT(value)
+	if err != nil {
+		e.add(fn, field, line, value, err)
+	}
+	return i
+}
+
+// parseFloat parses a float value, recording a failure in e
+func (e *ParseErrors) parseFloat(fn, field string, line int, value string) float64 {
+	f, err := toFLO(value)
+	if err != nil {
+		e.add(fn, field, line, value, err)
+	}
+	return f
+}
+
+// parseBool parses a True/False or Yes/No value, recording a failure in e
+func (e *ParseErrors) parseBool(fn, field string, line int, value string) bool {
+	b, err := toBOOL(value)
+	if err != nil {
+		e.add(fn, field, line, value, err)
+	}
+	return b
+}
+
+// parseSize parses a size to bytes with the given base, see toBytes,
+// recording a failure in e
+func (e *ParseErrors) parseSize(fn, field string, line int, value string, base float64) float64 {
+	f, err := toBytes(value, base)
+	if err != nil {
+		e.add(fn, field, line, value, err)
+	}
+	return f
+}
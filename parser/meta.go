@@ -1,27 +1,92 @@
 package parser
 
 import (
-	"log"
+	"errors"
+	"math"
 	"strconv"
 	"strings"
 )
 
-func toINT(s string) int64 {
+var (
+	errNotBool = errors.New("not a boolean")
+	errNotSize = errors.New("not a size")
+)
+
+// sizeUnits are the exponents of the units of the sizes reported by ssacli
+var sizeUnits = map[string]float64{
+	"B":  0,
+	"KB": 1,
+	"MB": 2,
+	"GB": 3,
+	"TB": 4,
+	"PB": 5,
+}
+
+func toINT(s string) (int64, error) {
 	i, err := strconv.Atoi(s)
 	if err != nil {
-		log.Fatalln(err)
+		return 0, err
 	}
-	return int64(i)
+	return int64(i), nil
 }
 
-func toFLO(s string) float64 {
+func toFLO(s string) (float64, error) {
 	i, err := strconv.ParseFloat(s, 64)
 	if err != nil {
-		log.Fatalln(err)
+		return 0, err
+	}
+	return float64(i), nil
+}
+
+func toBOOL(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "true", "yes":
+		return true, nil
+	case "false", "no":
+		return false, nil
 	}
-	return float64(i)
+	return false, errNotBool
+}
+
+// toBytes converts a size such as `1.8 TB` or `256 KB`, possibly followed
+// by more text as in `0 MB (0.00%)`, to bytes. The number may use a decimal
+// comma, e.g. `1,8 TB`. base is 1000 for the sizes of drives and 1024 for
+// those of memory, e.g. the cache, or of strips.
+func toBytes(s string, base float64) (float64, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return 0, errNotSize
+	}
+	exp, ok := sizeUnits[strings.ToUpper(fields[1])]
+	if !ok {
+		return 0, errNotSize
+	}
+	f, err := toFLO(strings.Replace(fields[0], ",", ".", 1))
+	if err != nil {
+		return 0, err
+	}
+	return f * math.Pow(base, exp), nil
+}
+
+// withUnit appends unit to a size that is a bare number, e.g. the cache
+// sizes some ssacli versions print in GB without a unit
+func withUnit(s, unit string) string {
+	if len(strings.Fields(s)) == 1 {
+		return s + " " + unit
+	}
+	return s
 }
 
 func trim(s string) string {
 	return strings.Trim(s, " \t")
 }
+
+// firstField returns the first whitespace separated field of s, e.g. the
+// number of a value followed by its unit
+func firstField(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestToBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		base    float64
+		want    float64
+		wantErr bool
+	}{
+		{name: "TB", in: "1.8 TB", base: 1000, want: 1.8e12},
+		{name: "GB", in: "600 GB", base: 1000, want: 600e9},
+		{name: "MB", in: "0 MB (0.00%)", base: 1000, want: 0},
+		{name: "KB base 1024", in: "256 KB", base: 1024, want: 262144},
+		{name: "GB base 1024", in: "2.0 GB", base: 1024, want: 2 * 1024 * 1024 * 1024},
+		{name: "lower case unit", in: "512 kb", base: 1024, want: 524288},
+		{name: "decimal comma", in: "1,8 TB", base: 1000, want: 1.8e12},
+		{name: "unitless", in: "2.0", base: 1024, wantErr: true},
+		{name: "unknown unit", in: "2.0 GiB", base: 1024, wantErr: true},
+		{name: "not a number", in: "None GB", base: 1000, wantErr: true},
+		{name: "empty", in: "", base: 1000, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := toBytes(tt.in, tt.base)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("toBytes(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("toBytes(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithUnit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{in: "2.0", want: 2 * 1024 * 1024 * 1024},
+		{in: "1,8", want: 1.8 * 1024 * 1024 * 1024},
+		{in: "1.8 GB", want: 1.8 * 1024 * 1024 * 1024},
+		{in: "4096 MB", want: 4 * 1024 * 1024 * 1024},
+	}
+
+	for _, tt := range tests {
+		got, err := toBytes(withUnit(tt.in, "GB"), 1024)
+		if err != nil {
+			t.Fatalf("toBytes(withUnit(%q)) error = %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("toBytes(withUnit(%q)) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseSsacliSumCacheSize(t *testing.T) {
+	const out = `
+Smart Array P420i in Slot 0 (Embedded)
+   Slot: 0
+   Total Cache Size: 2.0
+   Total Cache Memory Available: 1.8
+`
+	config, err := ParseSsacliConfig(out)
+	if err != nil {
+		t.Fatalf("ParseSsacliConfig() error = %v", err)
+	}
+	sum := config.Controllers[0].SsacliSumData
+	if sum.TotalCacheSize != 2*1024*1024*1024 {
+		t.Errorf("TotalCacheSize = %v, want 2 GiB", sum.TotalCacheSize)
+	}
+	if sum.AvailCacheSize != 1.8*1024*1024*1024 {
+		t.Errorf("AvailCacheSize = %v, want 1.8 GiB", sum.AvailCacheSize)
+	}
+}
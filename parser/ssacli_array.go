@@ -0,0 +1,75 @@
+package parser
+
+// SsacliArray data structure for output
+type SsacliArray struct {
+	SsacliArrayData []SsacliArrayData
+}
+
+// SsacliArrayData data structure for output
+type SsacliArrayData struct {
+	ID            string
+	Status        string
+	ArrayType     string
+	InterfaceType string
+	SpareType     string
+	UnusedSpace   float64
+	UsedSpace     float64
+	ParseErrs     ParseErrors
+}
+
+// ParseSsacliArray return the arrays listed by
+// `ssacli ctrl slot=N array all show detail`, along with ParseErrors for
+// the values that could not be parsed
+func ParseSsacliArray(s string) (*SsacliArray, error) {
+	data, errs := parseSsacliArray(s)
+
+	return data, errs.errs()
+}
+
+func parseSsacliArray(s string) (*SsacliArray, ParseErrors) {
+
+	var (
+		data SsacliArray
+		errs ParseErrors
+	)
+
+	for _, section := range ParseSsacliTree(s).Find("Array") {
+		tmp, arrayErrs := parseSsacliArraySection(section)
+		data.SsacliArrayData = append(data.SsacliArrayData, tmp)
+		errs = append(errs, arrayErrs...)
+	}
+
+	return &data, errs
+}
+
+// parseSsacliArraySection reads the properties of an array section
+func parseSsacliArraySection(section *SsacliSection) (SsacliArrayData, ParseErrors) {
+
+	var (
+		tmp  SsacliArrayData
+		errs ParseErrors
+	)
+
+	tmp.ID = sectionID(section.Header)
+
+	for _, prop := range section.Props {
+		switch prop.Key {
+		case "Status":
+			tmp.Status = prop.Value
+		case "Array Type":
+			tmp.ArrayType = prop.Value
+		case "Interface Type":
+			tmp.InterfaceType = prop.Value
+		case "Spare Type":
+			tmp.SpareType = prop.Value
+		case "Unused Space":
+			// e.g. `0 MB (0.00%)`
+			tmp.UnusedSpace = errs.parseSize("parseSsacliArray", prop.Key, prop.Line, prop.Value, 1000)
+		case "Used Space":
+			tmp.UsedSpace = errs.parseSize("parseSsacliArray", prop.Key, prop.Line, prop.Value, 1000)
+		}
+	}
+
+	tmp.ParseErrs = errs
+	return tmp, errs
+}
@@ -0,0 +1,255 @@
+package parser
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SsacliConfig is the topology of every controller, as reported by
+// `ssacli ctrl all show config detail`
+type SsacliConfig struct {
+	Controllers []SsacliConfigController
+}
+
+// SsacliConfigController is a controller with its arrays and the physical
+// drives that are not part of any array
+type SsacliConfigController struct {
+	// Header is the line naming the controller, e.g.
+	// `Smart Array P440ar in Slot 0 (Embedded)`
+	Header        string
+	SlotID        string
+	SsacliSumData SsacliSumData
+	ParseErrs     ParseErrors
+
+	Arrays     []SsacliConfigArray
+	Unassigned []SsacliConfigPhysDisk
+	// HBADrives are the drives passed through to the host by a controller
+	// in HBA or mixed mode, which belong to no array but are in use
+	HBADrives []SsacliConfigPhysDisk
+
+	// Section holds every property and child section of the controller,
+	// including those not modelled above
+	Section *SsacliSection
+}
+
+// SsacliConfigArray is an array with its logical and physical drives
+type SsacliConfigArray struct {
+	ID        string
+	Status    string
+	LogDisks  []SsacliConfigLogDisk
+	PhysDisks []SsacliConfigPhysDisk
+
+	Section *SsacliSection
+}
+
+// SsacliConfigLogDisk is a logical drive of an array
+type SsacliConfigLogDisk struct {
+	ID                string
+	SsacliLogDiskData SsacliLogDiskData
+	ParseErrs         ParseErrors
+
+	Section *SsacliSection
+}
+
+// SsacliConfigPhysDisk is a physical drive, either a member or a spare of
+// an array, or unassigned
+type SsacliConfigPhysDisk struct {
+	ID                 string
+	SsacliPhysDiskData SsacliPhysDiskData
+	ParseErrs          ParseErrors
+
+	Section *SsacliSection
+}
+
+// ParseSsacliConfig return the topology of the controllers, along with
+// ParseErrors for the values that could not be parsed
+func ParseSsacliConfig(s string) (*SsacliConfig, error) {
+	data, errs := parseSsacliConfig(s)
+
+	return data, errs.errs()
+}
+
+func parseSsacliConfig(s string) (*SsacliConfig, ParseErrors) {
+
+	var (
+		data SsacliConfig
+		errs ParseErrors
+	)
+
+	// Every section that is not indented describes a controller
+	for _, section := range ParseSsacliTree(s).Children {
+		if len(section.Props) == 0 {
+			continue
+		}
+
+		sum, sumErrs := parseSsacliSumSection(section)
+		errs = append(errs, sumErrs...)
+
+		con := SsacliConfigController{
+			Header:        section.Header,
+			SlotID:        sum.SlotID,
+			SsacliSumData: sum,
+			ParseErrs:     sumErrs,
+			Section:       section,
+		}
+
+		for _, child := range section.Children {
+			switch {
+			case strings.HasPrefix(child.Header, "Array"):
+				array := SsacliConfigArray{
+					ID:      sectionID(child.Header),
+					Status:  child.Value("Status"),
+					Section: child,
+				}
+				for _, ld := range detailSections(child, "Logical Drive") {
+					logDisk, ldErrs := parseSsacliConfigLogDisk(ld)
+					errs = append(errs, ldErrs...)
+					array.LogDisks = append(array.LogDisks, logDisk)
+				}
+				for _, pd := range detailSections(child, "physicaldrive") {
+					physDisk, pdErrs := parseSsacliConfigPhysDisk(pd)
+					errs = append(errs, pdErrs...)
+					array.PhysDisks = append(array.PhysDisks, physDisk)
+				}
+				con.Arrays = append(con.Arrays, array)
+			case child.Header == "Unassigned":
+				for _, pd := range detailSections(child, "physicaldrive") {
+					physDisk, pdErrs := parseSsacliConfigPhysDisk(pd)
+					errs = append(errs, pdErrs...)
+					con.Unassigned = append(con.Unassigned, physDisk)
+				}
+			case child.Header == "HBA Drives":
+				for _, pd := range detailSections(child, "physicaldrive") {
+					physDisk, pdErrs := parseSsacliConfigPhysDisk(pd)
+					errs = append(errs, pdErrs...)
+					con.HBADrives = append(con.HBADrives, physDisk)
+				}
+			}
+		}
+
+		data.Controllers = append(data.Controllers, con)
+	}
+
+	return &data, errs
+}
+
+// detailSections returns the direct children of s whose header starts with
+// prefix and that hold properties. The one-line listings of the drives, e.g.
+// those under the `Mirror Group 1:` sections of a logical drive, are left
+// out.
+func detailSections(s *SsacliSection, prefix string) []*SsacliSection {
+	found := make([]*SsacliSection, 0)
+	for _, child := range s.Children {
+		if strings.HasPrefix(child.Header, prefix) && len(child.Props) > 0 {
+			found = append(found, child)
+		}
+	}
+	return found
+}
+
+func parseSsacliConfigLogDisk(section *SsacliSection) (SsacliConfigLogDisk, ParseErrors) {
+	tmp, errs := parseSsacliLogDiskSection(section)
+	return SsacliConfigLogDisk{
+		ID:                sectionID(section.Header),
+		SsacliLogDiskData: tmp,
+		ParseErrs:         errs,
+		Section:           section,
+	}, errs
+}
+
+func parseSsacliConfigPhysDisk(section *SsacliSection) (SsacliConfigPhysDisk, ParseErrors) {
+	tmp, errs := parseSsacliPhysDiskSection(section)
+	return SsacliConfigPhysDisk{
+		ID:                 sectionID(section.Header),
+		SsacliPhysDiskData: tmp,
+		ParseErrs:          errs,
+		Section:            section,
+	}, errs
+}
+
+// PhysDisks return every physical drive of the controller, sorted by port,
+// box and bay like `ssacli ctrl slot=N pd all show status` lists them
+func (c *SsacliConfigController) PhysDisks() []SsacliConfigPhysDisk {
+	physDisks := make([]SsacliConfigPhysDisk, 0)
+	for _, array := range c.Arrays {
+		physDisks = append(physDisks, array.PhysDisks...)
+	}
+	physDisks = append(physDisks, c.Unassigned...)
+	physDisks = append(physDisks, c.HBADrives...)
+
+	sort.SliceStable(physDisks, func(i, j int) bool {
+		return lessPhysDiskID(physDisks[i].ID, physDisks[j].ID)
+	})
+	return physDisks
+}
+
+// LogDisks return every logical drive of the controller
+func (c *SsacliConfigController) LogDisks() []SsacliConfigLogDisk {
+	logDisks := make([]SsacliConfigLogDisk, 0)
+	for _, array := range c.Arrays {
+		logDisks = append(logDisks, array.LogDisks...)
+	}
+	return logDisks
+}
+
+// HasEnclosures tells whether the configuration lists a drive cage or an
+// enclosure of the controller
+func (c *SsacliConfigController) HasEnclosures() bool {
+	if c.Section == nil {
+		return false
+	}
+
+	found := false
+	c.Section.Walk(func(section *SsacliSection) {
+		found = found || isEnclosureHeader(section.Header)
+	})
+	return found
+}
+
+// SplitPhysDiskID return the port, box and bay of a physical drive ID such as
+// `1I:1:4`. The box is empty for IDs of the form port:bay.
+func SplitPhysDiskID(id string) (string, string, string) {
+	parts := strings.Split(id, ":")
+	switch len(parts) {
+	case 3:
+		return parts[0], parts[1], parts[2]
+	case 2:
+		return parts[0], "", parts[1]
+	default:
+		return id, "", ""
+	}
+}
+
+// sectionID returns the identifier in a section header, e.g. `1I:1:1` for
+// `physicaldrive 1I:1:1`, `1` for `Logical Drive: 1` and `A` for both
+// `Array A` and `Array: A`
+func sectionID(header string) string {
+	if _, id, ok := strings.Cut(header, ": "); ok {
+		return trim(id)
+	}
+	fields := strings.Fields(header)
+	if len(fields) < 2 {
+		return ""
+	}
+	return fields[1]
+}
+
+// lessPhysDiskID orders physical drive IDs of the form port:box:bay, the
+// box and bay being compared as numbers
+func lessPhysDiskID(a, b string) bool {
+	as := strings.Split(a, ":")
+	bs := strings.Split(b, ":")
+	for k := 0; k < len(as) && k < len(bs); k++ {
+		if as[k] == bs[k] {
+			continue
+		}
+		an, aErr := strconv.Atoi(as[k])
+		bn, bErr := strconv.Atoi(bs[k])
+		if aErr == nil && bErr == nil {
+			return an < bn
+		}
+		return as[k] < bs[k]
+	}
+	return len(as) < len(bs)
+}
@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"slices"
+	"testing"
+)
+
+// configMirrorGroups is a RAID 1+0 array, whose logical drive lists its
+// members again under its mirror groups, an unassigned drive and a drive
+// passed through to the host
+const configMirrorGroups = `
+Smart Array P440ar in Slot 0 (Embedded)
+   Slot: 0
+   Controller Status: OK
+
+   Array: A
+      Interface Type: SAS
+      Status: OK
+
+      Logical Drive: 1
+         Size: 1.2 TB
+         Fault Tolerance: 1+0
+         Status: OK
+         Mirror Group 1:
+            physicaldrive 1I:1:1 (port 1I:box 1:bay 1, SAS HDD, 600 GB, OK)
+            physicaldrive 1I:1:2 (port 1I:box 1:bay 2, SAS HDD, 600 GB, OK)
+         Mirror Group 2:
+            physicaldrive 1I:1:3 (port 1I:box 1:bay 3, SAS HDD, 600 GB, OK)
+            physicaldrive 1I:1:4 (port 1I:box 1:bay 4, SAS HDD, 600 GB, OK)
+         Drive Type: Data
+
+      physicaldrive 1I:1:1
+         Status: OK
+         Drive Type: Data Drive
+         Size: 600 GB
+
+      physicaldrive 1I:1:2
+         Status: OK
+         Drive Type: Data Drive
+         Size: 600 GB
+
+      physicaldrive 1I:1:3
+         Status: OK
+         Drive Type: Data Drive
+         Size: 600 GB
+
+      physicaldrive 1I:1:4
+         Status: OK
+         Drive Type: Data Drive
+         Size: 600 GB
+
+      physicaldrive 1I:1:5
+         Status: OK
+         Drive Type: Spare Drive
+         Size: 600 GB
+
+   Unassigned
+
+      physicaldrive 1I:1:6
+         Status: OK
+         Drive Type: Unassigned Drive
+         Size: 400 GB
+
+   HBA Drives
+
+      physicaldrive 2I:1:1
+         Status: OK
+         Drive Type: HBA Mode Drive
+         Size: 1 TB
+`
+
+func TestParseSsacliConfig(t *testing.T) {
+	config, err := ParseSsacliConfig(configMirrorGroups)
+	if err != nil {
+		t.Fatalf("ParseSsacliConfig() error = %v", err)
+	}
+	if len(config.Controllers) != 1 {
+		t.Fatalf("got %d controllers, want 1", len(config.Controllers))
+	}
+
+	con := config.Controllers[0]
+	if con.SlotID != "0" {
+		t.Errorf("SlotID = %q, want %q", con.SlotID, "0")
+	}
+	if len(con.Arrays) != 1 {
+		t.Fatalf("got %d arrays, want 1", len(con.Arrays))
+	}
+
+	array := con.Arrays[0]
+	if array.ID != "A" || array.Status != "OK" {
+		t.Errorf("array = %q %q, want %q %q", array.ID, array.Status, "A", "OK")
+	}
+	if len(array.LogDisks) != 1 || array.LogDisks[0].ID != "1" {
+		t.Fatalf("got logical drives %+v, want only 1", array.LogDisks)
+	}
+	if ft := array.LogDisks[0].SsacliLogDiskData.FaultTolerance; ft != "1+0" {
+		t.Errorf("FaultTolerance = %q, want %q", ft, "1+0")
+	}
+
+	ids := make([]string, 0)
+	for _, physDisk := range array.PhysDisks {
+		ids = append(ids, physDisk.ID)
+		if physDisk.SsacliPhysDiskData.Status == "" || physDisk.SsacliPhysDiskData.DriveType == "" {
+			t.Errorf("physical drive %s has no status or drive type", physDisk.ID)
+		}
+	}
+	want := []string{"1I:1:1", "1I:1:2", "1I:1:3", "1I:1:4", "1I:1:5"}
+	if !slices.Equal(ids, want) {
+		t.Errorf("array physical drives = %v, want %v", ids, want)
+	}
+
+	if len(con.Unassigned) != 1 || con.Unassigned[0].ID != "1I:1:6" {
+		t.Errorf("got unassigned drives %+v, want only 1I:1:6", con.Unassigned)
+	}
+	if len(con.HBADrives) != 1 || con.HBADrives[0].ID != "2I:1:1" {
+		t.Errorf("got HBA drives %+v, want only 2I:1:1", con.HBADrives)
+	}
+	if n := len(con.PhysDisks()); n != 7 {
+		t.Errorf("PhysDisks() returned %d drives, want 7", n)
+	}
+}
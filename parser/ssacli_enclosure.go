@@ -0,0 +1,170 @@
+package parser
+
+import (
+	"strings"
+)
+
+// SsacliEnclosure data structure for output
+type SsacliEnclosure struct {
+	SsacliEnclosureData []SsacliEnclosureData
+}
+
+// SsacliEnclosureData data structure for output
+type SsacliEnclosureData struct {
+	// ID is the port and box of the enclosure, e.g. `1I:1`, the prefix of
+	// the ID of the physical drives it holds
+	ID                string
+	Name              string
+	Status            string
+	Port              string
+	Box               string
+	Location          string
+	VendorID          string
+	SerialNumber      string
+	FirmVersion       string
+	FanStatus         string
+	TempStatus        string
+	PowerSupplyStatus string
+	DriveBays         float64
+	Expanders         []SsacliExpanderData
+	Sensors           []SsacliSensorData
+	ParseErrs         ParseErrors
+}
+
+// SsacliExpanderData data structure for output
+type SsacliExpanderData struct {
+	ID          string
+	FirmVersion string
+}
+
+// SsacliSensorData data structure for output
+type SsacliSensorData struct {
+	ID       string
+	Location string
+	CurTemp  float64
+	MaxTemp  float64
+	Section  *SsacliSection
+}
+
+// ParseSsacliEnclosure return the enclosures listed by
+// `ssacli ctrl slot=N enclosure all show detail`, along with ParseErrors for
+// the values that could not be parsed
+func ParseSsacliEnclosure(s string) (*SsacliEnclosure, error) {
+	data, errs := parseSsacliEnclosure(s)
+
+	return data, errs.errs()
+}
+
+func parseSsacliEnclosure(s string) (*SsacliEnclosure, ParseErrors) {
+
+	var (
+		data SsacliEnclosure
+		errs ParseErrors
+	)
+
+	tree := ParseSsacliTree(s)
+
+	// The expanders of an enclosure are listed after it, at the same level,
+	// and are told apart by their port and box
+	expanders := tree.Find("Expander")
+
+	tree.Walk(func(section *SsacliSection) {
+		if !isEnclosureHeader(section.Header) {
+			return
+		}
+
+		tmp, enclosureErrs := parseSsacliEnclosureSection(section)
+		for _, expander := range expanders {
+			if expander.Value("Port") == tmp.Port && expander.Value("Box") == tmp.Box {
+				tmp.Expanders = append(tmp.Expanders, SsacliExpanderData{
+					ID:          expander.Value("Device Number"),
+					FirmVersion: expander.Value("Firmware Version"),
+				})
+			}
+		}
+
+		data.SsacliEnclosureData = append(data.SsacliEnclosureData, tmp)
+		errs = append(errs, enclosureErrs...)
+	})
+
+	return &data, errs
+}
+
+// isEnclosureHeader tells whether a section header is that of a drive cage
+// or an enclosure, e.g. `Internal Drive Cage at Port 1I, Box 1, OK`
+func isEnclosureHeader(header string) bool {
+	return strings.Contains(header, " at Port ")
+}
+
+// parseSsacliEnclosureSection reads the properties and sensors of an
+// enclosure section, whose header is e.g.
+// `Internal Drive Cage at Port 1I, Box 1, OK`
+func parseSsacliEnclosureSection(section *SsacliSection) (SsacliEnclosureData, ParseErrors) {
+
+	var (
+		tmp  SsacliEnclosureData
+		errs ParseErrors
+	)
+
+	name, rest, _ := strings.Cut(section.Header, " at Port ")
+	fields := strings.Split(rest, ",")
+	tmp.Name = trim(name)
+	tmp.Port = trim(fields[0])
+	if len(fields) > 1 {
+		tmp.Box = trim(strings.TrimPrefix(trim(fields[1]), "Box"))
+	}
+	if len(fields) > 2 {
+		tmp.Status = trim(strings.Join(fields[2:], ","))
+	}
+
+	for _, prop := range section.Props {
+		switch prop.Key {
+		case "Port":
+			tmp.Port = prop.Value
+		case "Box":
+			tmp.Box = prop.Value
+		case "Location":
+			tmp.Location = prop.Value
+		case "Vendor ID":
+			tmp.VendorID = prop.Value
+		case "Serial Number":
+			tmp.SerialNumber = prop.Value
+		case "Firmware Version":
+			tmp.FirmVersion = prop.Value
+		case "Fan Status":
+			tmp.FanStatus = prop.Value
+		case "Temperature Status":
+			tmp.TempStatus = prop.Value
+		case "Power Supply Status":
+			tmp.PowerSupplyStatus = prop.Value
+		case "Drive Bays":
+			tmp.DriveBays = errs.parseFloat("parseSsacliEnclosure", prop.Key, prop.Line, prop.Value)
+		}
+	}
+
+	// Enclosures with temperature sensors list them like the controller
+	// does, a `Sensor ID: 1` section holding e.g. `Current Value (C): 26`
+	for _, child := range section.Children {
+		if !strings.HasPrefix(child.Header, "Sensor ID") {
+			continue
+		}
+		sensor := SsacliSensorData{
+			ID:       sectionID(child.Header),
+			Location: child.Value("Location"),
+			Section:  child,
+		}
+		for _, prop := range child.Props {
+			switch prop.Key {
+			case "Current Value (C)":
+				sensor.CurTemp = errs.parseFloat("parseSsacliEnclosure", "Sensor "+sensor.ID+" "+prop.Key, prop.Line, prop.Value)
+			case "Max Value Since Power On":
+				sensor.MaxTemp = errs.parseFloat("parseSsacliEnclosure", "Sensor "+sensor.ID+" "+prop.Key, prop.Line, prop.Value)
+			}
+		}
+		tmp.Sensors = append(tmp.Sensors, sensor)
+	}
+
+	tmp.ID = tmp.Port + ":" + tmp.Box
+	tmp.ParseErrs = errs
+	return tmp, errs
+}
@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"testing"
+)
+
+const enclosureOutput = `
+Smart Array P440ar in Slot 0 (Embedded)
+
+   Internal Drive Cage at Port 1I, Box 1, OK
+
+      Fan Status: OK
+      Temperature Status: OK
+      Power Supply Status: Not Redundant
+      Vendor ID: HPE
+      Serial Number: 5CE0000000
+      Firmware Version: 1.86
+      Drive Bays: 8
+      Port: 1I
+      Box: 1
+      Location: Internal
+
+      Sensor ID: 1
+         Location: Drive Backplane
+         Current Value (C): 29
+         Max Value Since Power On: 33
+
+   Storage Enclosure at Port 1E, Box 2, Failed
+
+      Fan Status: Failed
+      Power Supply Status: Redundant
+      Drive Bays: many
+      Port: 1E
+      Box: 2
+      Location: External
+
+   Expander 377
+      Device Number: 377
+      Firmware Version: 1.86
+      Port: 1I
+      Box: 1
+
+   Expander 378
+      Device Number: 378
+      Firmware Version: 4.10
+      Port: 1E
+      Box: 2
+`
+
+func TestParseSsacliEnclosure(t *testing.T) {
+	data, errs := parseSsacliEnclosure(enclosureOutput)
+
+	if len(data.SsacliEnclosureData) != 2 {
+		t.Fatalf("got %d enclosures, want 2", len(data.SsacliEnclosureData))
+	}
+	if len(errs) != 1 || errs[0].Field != "Drive Bays" {
+		t.Errorf("errors = %v, want only Drive Bays", errs)
+	}
+
+	cage := data.SsacliEnclosureData[0]
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "ID", got: cage.ID, want: "1I:1"},
+		{name: "Name", got: cage.Name, want: "Internal Drive Cage"},
+		{name: "Status", got: cage.Status, want: "OK"},
+		{name: "Location", got: cage.Location, want: "Internal"},
+		{name: "VendorID", got: cage.VendorID, want: "HPE"},
+		{name: "FirmVersion", got: cage.FirmVersion, want: "1.86"},
+		{name: "FanStatus", got: cage.FanStatus, want: "OK"},
+		{name: "TempStatus", got: cage.TempStatus, want: "OK"},
+		{name: "PowerSupplyStatus", got: cage.PowerSupplyStatus, want: "Not Redundant"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+	if cage.DriveBays != 8 {
+		t.Errorf("DriveBays = %v, want 8", cage.DriveBays)
+	}
+	if len(cage.Expanders) != 1 || cage.Expanders[0].ID != "377" || cage.Expanders[0].FirmVersion != "1.86" {
+		t.Errorf("Expanders = %+v, want expander 377", cage.Expanders)
+	}
+	if len(cage.Sensors) != 1 {
+		t.Fatalf("got %d sensors, want 1", len(cage.Sensors))
+	}
+	sensor := cage.Sensors[0]
+	if sensor.ID != "1" || sensor.Location != "Drive Backplane" || sensor.CurTemp != 29 || sensor.MaxTemp != 33 {
+		t.Errorf("sensor = %+v, want sensor 1 at 29 and 33", sensor)
+	}
+
+	enclosure := data.SsacliEnclosureData[1]
+	if enclosure.ID != "1E:2" || enclosure.Status != "Failed" || enclosure.FanStatus != "Failed" {
+		t.Errorf("enclosure = %+v, want 1E:2 failed", enclosure)
+	}
+	if !enclosure.ParseErrs.Failed("Drive Bays") {
+		t.Errorf("Drive Bays of 1E:2 not reported as failed")
+	}
+	if len(enclosure.Expanders) != 1 || enclosure.Expanders[0].ID != "378" {
+		t.Errorf("Expanders = %+v, want expander 378", enclosure.Expanders)
+	}
+	if len(enclosure.Sensors) != 0 {
+		t.Errorf("Sensors = %+v, want none", enclosure.Sensors)
+	}
+}
@@ -1,14 +1,5 @@
 package parser
 
-import (
-	"strings"
-)
-
-// SsacliLogDisk data structure for output
-type SsacliLogDisk struct {
-	SsacliLogDiskData SsacliLogDiskData
-}
-
 // SsacliLogDiskData data structure for output
 type SsacliLogDiskData struct {
 	Size      string
@@ -18,50 +9,65 @@ type SsacliLogDiskData struct {
 	UID       string
 	LName     string
 	LID       string
-}
 
-// ParseSsacliLogDisk return specific metric
-func ParseSsacliLogDisk(s string) *SsacliLogDisk {
-	data := parseSsacliLogDisk(s)
+	ParityInitStatus   string
+	ParityInitProgress string
 
-	return data
+	SizeBytes          float64
+	FaultTolerance     string
+	StripSize          float64
+	FullStripeSize     float64
+	Heads              float64
+	SectorsPerTrack    float64
+	MultiDomainStatus  string
+	AccelerationMethod string
 }
 
-func parseSsacliLogDisk(s string) *SsacliLogDisk {
+// parseSsacliLogDiskSection reads the properties of a logical drive section
+func parseSsacliLogDiskSection(section *SsacliSection) (SsacliLogDiskData, ParseErrors) {
 
 	var (
-		tmp SsacliLogDiskData
+		tmp  SsacliLogDiskData
+		errs ParseErrors
 	)
 
-	for _, line := range strings.Split(s, "\n") {
-		kvs := strings.Trim(line, " \t")
-		if kvs == "" {
-			continue
-		}
-
-		kv := strings.Split(kvs, ": ")
-
-		if len(kv) == 2 {
-
-			switch kv[0] {
-			case "Size":
-				tmp.Size = kv[1]
-			case "Cylinders":
-				tmp.Cylinders = toFLO(kv[1])
-			case "Status":
-				tmp.Status = kv[1]
-			case "Caching":
-				tmp.Caching = kv[1]
-			case "Unique Identifier":
-				tmp.UID = kv[1]
-			case "Disk Name":
-				tmp.LName = kv[1]
-			case "Logical Drive Label":
-				tmp.LID = kv[1]
-			}
+	for _, prop := range section.Props {
+		switch prop.Key {
+		case "Size":
+			tmp.Size = prop.Value
+			tmp.SizeBytes = errs.parseSize("parseSsacliLogDiskSection", prop.Key, prop.Line, prop.Value, 1000)
+		case "Fault Tolerance":
+			tmp.FaultTolerance = prop.Value
+		case "Strip Size":
+			tmp.StripSize = errs.parseSize("parseSsacliLogDiskSection", prop.Key, prop.Line, prop.Value, 1024)
+		case "Full Stripe Size":
+			tmp.FullStripeSize = errs.parseSize("parseSsacliLogDiskSection", prop.Key, prop.Line, prop.Value, 1024)
+		case "Heads":
+			tmp.Heads = errs.parseFloat("parseSsacliLogDiskSection", prop.Key, prop.Line, prop.Value)
+		case "Sectors Per Track":
+			tmp.SectorsPerTrack = errs.parseFloat("parseSsacliLogDiskSection", prop.Key, prop.Line, prop.Value)
+		case "MultiDomain Status":
+			tmp.MultiDomainStatus = prop.Value
+		case "LD Acceleration Method":
+			tmp.AccelerationMethod = prop.Value
+		case "Cylinders":
+			tmp.Cylinders = errs.parseFloat("parseSsacliLogDiskSection", prop.Key, prop.Line, prop.Value)
+		case "Status":
+			tmp.Status = prop.Value
+		case "Caching":
+			tmp.Caching = prop.Value
+		case "Unique Identifier":
+			tmp.UID = prop.Value
+		case "Disk Name":
+			tmp.LName = prop.Value
+		case "Logical Drive Label":
+			tmp.LID = prop.Value
+		case "Parity Initialization Status":
+			tmp.ParityInitStatus = prop.Value
+		case "Parity Initialization Progress":
+			tmp.ParityInitProgress = prop.Value
 		}
 	}
 
-	data := SsacliLogDisk{SsacliLogDiskData: tmp}
-	return &data
+	return tmp, errs
 }
@@ -1,14 +1,10 @@
 package parser
 
 import (
+	"math"
 	"strings"
 )
 
-// SsacliPhysDisk data structure for output
-type SsacliPhysDisk struct {
-	SsacliPhysDiskData SsacliPhysDiskData
-}
-
 // SsacliPhysDiskData data structure for output
 type SsacliPhysDiskData struct {
 	Bay       string
@@ -22,58 +18,106 @@ type SsacliPhysDiskData struct {
 	CurTemp   float64
 	MaxTemp   float64
 	Model     string
-}
 
-// ParseSsacliPhysDisk return specific metric
-func ParseSsacliPhysDisk(s string) *SsacliPhysDisk {
-	data := parseSsacliPhysDisk(s)
+	FirmRevision       string
+	PhyCount           float64
+	PhyTransferRate    []float64
+	PhyLinkRate        []float64
+	PhyMaxLinkRate     []float64
+	RotationalSpeed    float64
+	ExposedToOS        bool
+	CarrierAppVersion  string
+	CarrierBootVersion string
+	AuthStatus         string
+	SanitizeSupported  bool
+	LastFailureReason  string
 
-	return data
+	UsageRemaining    float64
+	LifeRemainingDays float64
+	SizeBytes         float64
 }
 
-func parseSsacliPhysDisk(s string) *SsacliPhysDisk {
+// parseSsacliPhysDiskSection reads the properties of a physical drive section
+func parseSsacliPhysDiskSection(section *SsacliSection) (SsacliPhysDiskData, ParseErrors) {
 
 	var (
-		tmp SsacliPhysDiskData
+		tmp  SsacliPhysDiskData
+		errs ParseErrors
 	)
-	for _, line := range strings.Split(s, "\n") {
-		kvs := strings.Trim(line, " \t")
-		if kvs == "" {
-			continue
-		}
 
-		kv := strings.Split(kvs, ": ")
+	for _, prop := range section.Props {
+		switch prop.Key {
+		case "Bay":
+			tmp.Bay = prop.Value
+		case "Serial Number":
+			tmp.SN = prop.Value
+		case "Status":
+			tmp.Status = prop.Value
+		case "Drive Type":
+			tmp.DriveType = prop.Value
+		case "Interface Type":
+			tmp.IntType = prop.Value
+		case "Size":
+			tmp.Size = prop.Value
+			tmp.SizeBytes = errs.parseSize("parseSsacliPhysDiskSection", prop.Key, prop.Line, prop.Value, 1000)
+		case "Logical/Physical Block Size":
+			tmp.BlockSize = prop.Value
+		case "WWID":
+			tmp.WWID = prop.Value
+		case "Model":
+			tmp.Model = prop.Value
+		case "Current Temperature (C)":
+			tmp.CurTemp = errs.parseFloat("parseSsacliPhysDiskSection", prop.Key, prop.Line, prop.Value)
+		case "Maximum Temperature (C)":
+			tmp.MaxTemp = errs.parseFloat("parseSsacliPhysDiskSection", prop.Key, prop.Line, prop.Value)
+		case "Firmware Revision":
+			tmp.FirmRevision = prop.Value
+		case "PHY Count":
+			tmp.PhyCount = errs.parseFloat("parseSsacliPhysDiskSection", prop.Key, prop.Line, prop.Value)
+		case "PHY Transfer Rate":
+			tmp.PhyTransferRate = errs.parsePhyRates("parseSsacliPhysDiskSection", prop.Key, prop.Line, prop.Value)
+		case "PHY Physical Link Rate":
+			tmp.PhyLinkRate = errs.parsePhyRates("parseSsacliPhysDiskSection", prop.Key, prop.Line, prop.Value)
+		case "PHY Maximum Link Rate":
+			tmp.PhyMaxLinkRate = errs.parsePhyRates("parseSsacliPhysDiskSection", prop.Key, prop.Line, prop.Value)
+		case "Rotational Speed":
+			tmp.RotationalSpeed = errs.parseFloat("parseSsacliPhysDiskSection", prop.Key, prop.Line, prop.Value)
+		case "Drive exposed to OS":
+			tmp.ExposedToOS = errs.parseBool("parseSsacliPhysDiskSection", prop.Key, prop.Line, prop.Value)
+		case "Carrier Application Version":
+			tmp.CarrierAppVersion = prop.Value
+		case "Carrier Bootloader Version":
+			tmp.CarrierBootVersion = prop.Value
+		case "Drive Authentication Status":
+			tmp.AuthStatus = prop.Value
+		case "Sanitize Erase Supported":
+			tmp.SanitizeSupported = errs.parseBool("parseSsacliPhysDiskSection", prop.Key, prop.Line, prop.Value)
+		case "Last Failure Reason":
+			tmp.LastFailureReason = prop.Value
+		case "Usage remaining":
+			// e.g. `97.30%`
+			tmp.UsageRemaining = errs.parseFloat("parseSsacliPhysDiskSection", prop.Key, prop.Line, strings.TrimSuffix(prop.Value, "%"))
+		case "Estimated Life Remaining based on workload to date":
+			// e.g. `31200 days`
+			tmp.LifeRemainingDays = errs.parseFloat("parseSsacliPhysDiskSection", prop.Key, prop.Line, firstField(prop.Value))
+		}
+	}
 
-		if len(kv) == 2 {
+	return tmp, errs
+}
 
-			switch kv[0] {
-			case "Bay":
-				tmp.Bay = kv[1]
-			case "Serial Number":
-				tmp.SN = kv[1]
-			case "Status":
-				tmp.Status = kv[1]
-			case "Drive Type":
-				tmp.DriveType = kv[1]
-			case "Interface Type":
-				tmp.IntType = kv[1]
-			case "Size":
-				tmp.Size = kv[1]
-			case "Logical/Physical Block Size":
-				tmp.BlockSize = kv[1]
-			case "WWID":
-				tmp.WWID = kv[1]
-			case "Model":
-				tmp.Model = kv[1]
-			case "Current Temperature (C)":
-				tmp.CurTemp = toFLO(kv[1])
-			case "Maximum Temperature (C)":
-				tmp.MaxTemp = toFLO(kv[1])
-			}
+// parsePhyRates parses the rate of each PHY of a drive in Gbps, e.g.
+// `6.0Gbps, Unknown`, recording a failure in e. The rate of a PHY reported as
+// Unknown is NaN.
+func (e *ParseErrors) parsePhyRates(fn, field string, line int, value string) []float64 {
+	rates := make([]float64, 0)
+	for _, rate := range strings.Split(value, ",") {
+		rate = trim(rate)
+		if rate == "Unknown" {
+			rates = append(rates, math.NaN())
+			continue
 		}
+		rates = append(rates, e.parseFloat(fn, field, line, strings.TrimSuffix(rate, "Gbps")))
 	}
-
-	data := SsacliPhysDisk{
-		SsacliPhysDiskData: tmp}
-	return &data
+	return rates
 }
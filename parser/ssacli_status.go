@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"strings"
+)
+
+// SsacliStatus is a drive listed by `ssacli ctrl slot=N pd all show status`
+// or `ssacli ctrl slot=N ld all show status`
+type SsacliStatus struct {
+	ID     string
+	Status string
+}
+
+// ParseSsacliStatus return the drives listed in the output, lines such as
+// `physicaldrive 1I:1:1 (port 1I:box 1:bay 1, 600 GB): OK` or
+// `logicaldrive 1 (1.1 TB, RAID 5): OK`
+func ParseSsacliStatus(s string) []SsacliStatus {
+	statuses := make([]SsacliStatus, 0)
+
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || (fields[0] != "physicaldrive" && fields[0] != "logicaldrive") {
+			continue
+		}
+
+		status := ""
+		if i := strings.LastIndex(line, ": "); i >= 0 {
+			status = trim(line[i+2:])
+		}
+
+		statuses = append(statuses, SsacliStatus{ID: fields[1], Status: status})
+	}
+
+	return statuses
+}
+
+// SsacliState return the state of a status, without the details ssacli may
+// append to it, e.g. `Recovering` for `Recovering, 43% complete`
+func SsacliState(status string) string {
+	state, _, _ := strings.Cut(status, ",")
+	return trim(state)
+}
+
+// SsacliProgress return the state and the completion percentage of a status
+// reporting the progress of an operation, such as `Recovering, 43% complete`,
+// or of a bare `17% complete`. ok is false when the status holds no
+// percentage.
+func SsacliProgress(status string) (string, float64, bool) {
+	state, rest, found := strings.Cut(status, ",")
+	if !found {
+		state, rest = "", status
+	}
+
+	value, complete := strings.CutSuffix(trim(rest), "% complete")
+	percent, err := toFLO(trim(value))
+	if !complete || err != nil {
+		return SsacliState(status), 0, false
+	}
+	return trim(state), percent, true
+}
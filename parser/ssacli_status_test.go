@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestSsacliProgress(t *testing.T) {
+	tests := []struct {
+		status  string
+		state   string
+		percent float64
+		ok      bool
+	}{
+		{status: "Recovering, 43% complete", state: "Recovering", percent: 43, ok: true},
+		{status: "Transforming, 0.5% complete", state: "Transforming", percent: 0.5, ok: true},
+		{status: "17% complete", state: "", percent: 17, ok: true},
+		{status: "Initialization Completed", state: "Initialization Completed", ok: false},
+		{status: "OK", state: "OK", ok: false},
+		{status: "Failed, see log", state: "Failed", ok: false},
+		{status: "", state: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		state, percent, ok := SsacliProgress(tt.status)
+		if state != tt.state || percent != tt.percent || ok != tt.ok {
+			t.Errorf("SsacliProgress(%q) = %q, %v, %v, want %q, %v, %v", tt.status, state, percent, ok, tt.state, tt.percent, tt.ok)
+		}
+	}
+}
+
+func TestSsacliState(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{status: "OK", want: "OK"},
+		{status: "Recovering, 43% complete", want: "Recovering"},
+		{status: " Predictive Failure ", want: "Predictive Failure"},
+		{status: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := SsacliState(tt.status); got != tt.want {
+			t.Errorf("SsacliState(%q) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
@@ -4,12 +4,6 @@ import (
 	"strings"
 )
 
-// SsacliSum data structure for output
-type SsacliSum struct {
-	ContNumber    int
-	SsacliSumData []SsacliSumData
-}
-
 // SsacliSumData data structure for output
 type SsacliSumData struct {
 	Slot           int64
@@ -26,79 +20,129 @@ type SsacliSumData struct {
 	Encryption     string
 	DriverName     string
 	DriverVersion  string
+
+	Model               string
+	Mode                string
+	HardwareRevision    string
+	CacheBoardPresent   bool
+	CacheStatus         string
+	CacheRatioRead      float64
+	CacheRatioWrite     float64
+	DriveWriteCache     string
+	NoBatteryWriteCache string
+	RebuildPriority     string
+	ExpandPriority      string
+	SurfaceScanMode     string
+	SurfaceScanDelay    float64
+	SpareActivationMode string
+	PowerMode           string
+	SurvivalMode        string
+	Ports               float64
+
+	BackupPowerSource  string
+	BatteryCount       float64
+	CacheStatusDetails string
 }
 
-// ParseSsacliSum return specific metric
-func ParseSsacliSum(s string) *SsacliSum {
-	data := parseSmartAttrs(s)
+// WriteCacheDisabledByBattery reports whether the write cache of the
+// controller is off because its batteries or capacitors cannot back it up:
+// either the cache is disabled for that reason, or they are not OK and the
+// cache is not allowed to run without them.
+func (d SsacliSumData) WriteCacheDisabledByBattery() bool {
+	if d.CacheStatus != "" && d.CacheStatus != "OK" {
+		details := strings.ToLower(d.CacheStatusDetails)
+		if strings.Contains(details, "batter") || strings.Contains(details, "capacitor") || strings.Contains(details, "backup power") {
+			return true
+		}
+	}
 
-	return data
+	return d.BatteryStatus != "" && d.BatteryStatus != "OK" && d.NoBatteryWriteCache == "Disabled"
 }
 
-func parseSmartAttrs(s string) *SsacliSum {
+// parseSsacliSumSection reads the properties of a controller section
+func parseSsacliSumSection(section *SsacliSection) (SsacliSumData, ParseErrors) {
 
 	var (
-		contNumber int
-		sumData    []SsacliSumData
+		tmp  SsacliSumData
+		errs ParseErrors
 	)
 
-	contNumber = 0
-
-	for _, line := range strings.Split(s, "\n") {
-		kvs := strings.Trim(line, " \t")
-		if kvs == "" {
-			continue
-		}
-
-		kv := strings.Split(kvs, ": ")
-
-		if len(kv) == 1 {
-			sumData = append(sumData, *new(SsacliSumData))
-			contNumber++
-		} else {
-			if len(sumData) != contNumber {
-				// We go out of whack somehow: todo, error logging
-				break
-			}
-
-			switch kv[0] {
-			case "Slot":
-				sumData[contNumber-1].Slot = toINT(kv[1])
-				sumData[contNumber-1].SlotID = kv[1]
-			case "Serial Number":
-				sumData[contNumber-1].SerialNumber = kv[1]
-			case "Controller Status":
-				sumData[contNumber-1].ContStatus = kv[1]
-			case "Firmware Version":
-				sumData[contNumber-1].FirmVersion = kv[1]
-			case "Total Cache Size":
-				cacheMem := strings.Split(kv[1], " ")
-				sumData[contNumber-1].TotalCacheSize = toFLO(cacheMem[0])
-			case "Total Cache Memory Available":
-				cacheMem := strings.Split(kv[1], " ")
-				sumData[contNumber-1].AvailCacheSize = toFLO(cacheMem[0])
-			case "Battery/Capacitor Status":
-				sumData[contNumber-1].BatteryStatus = kv[1]
-			case "Controller Temperature (C)":
-				sumData[contNumber-1].ContTemp = toFLO(kv[1])
-			case "Cache Module Temperature (C)":
-				sumData[contNumber-1].CacheModuTemp = toFLO(kv[1])
-			case "Capacitor Temperature  (C)":
-				sumData[contNumber-1].BatteryTemp = toFLO(kv[1])
-			case "Encryption":
-				sumData[contNumber-1].Encryption = kv[1]
-			case "Driver Name":
-				sumData[contNumber-1].DriverName = kv[1]
-			case "Driver Version":
-				sumData[contNumber-1].DriverVersion = kv[1]
-			}
+	// The header reads e.g. `Smart Array P440ar in Slot 0 (Embedded)`
+	tmp.Model, _, _ = strings.Cut(section.Header, " in Slot ")
 
+	for _, prop := range section.Props {
+		switch prop.Key {
+		case "Slot":
+			tmp.Slot = errs.parseInt("parseSsacliSumSection", prop.Key, prop.Line, prop.Value)
+			tmp.SlotID = prop.Value
+		case "Serial Number":
+			tmp.SerialNumber = prop.Value
+		case "Controller Status":
+			tmp.ContStatus = prop.Value
+		case "Firmware Version":
+			tmp.FirmVersion = prop.Value
+		case "Total Cache Size":
+			// e.g. `2.0 GB`, or `2.0` in GB without the unit
+			tmp.TotalCacheSize = errs.parseSize("parseSsacliSumSection", prop.Key, prop.Line, withUnit(prop.Value, "GB"), 1024)
+		case "Total Cache Memory Available":
+			tmp.AvailCacheSize = errs.parseSize("parseSsacliSumSection", prop.Key, prop.Line, withUnit(prop.Value, "GB"), 1024)
+		case "Battery/Capacitor Status":
+			tmp.BatteryStatus = prop.Value
+		case "Controller Temperature (C)":
+			tmp.ContTemp = errs.parseFloat("parseSsacliSumSection", prop.Key, prop.Line, prop.Value)
+		case "Cache Module Temperature (C)":
+			tmp.CacheModuTemp = errs.parseFloat("parseSsacliSumSection", prop.Key, prop.Line, prop.Value)
+		case "Capacitor Temperature  (C)":
+			tmp.BatteryTemp = errs.parseFloat("parseSsacliSumSection", prop.Key, prop.Line, prop.Value)
+		case "Encryption":
+			tmp.Encryption = prop.Value
+		case "Driver Name":
+			tmp.DriverName = prop.Value
+		case "Driver Version":
+			tmp.DriverVersion = prop.Value
+		case "Controller Mode":
+			tmp.Mode = prop.Value
+		case "Hardware Revision":
+			tmp.HardwareRevision = prop.Value
+		case "Cache Board Present":
+			tmp.CacheBoardPresent = errs.parseBool("parseSsacliSumSection", prop.Key, prop.Line, prop.Value)
+		case "Cache Status":
+			tmp.CacheStatus = prop.Value
+		case "Cache Status Details":
+			tmp.CacheStatusDetails = prop.Value
+		case "Cache Backup Power Source":
+			tmp.BackupPowerSource = prop.Value
+		case "Battery/Capacitor Count":
+			tmp.BatteryCount = errs.parseFloat("parseSsacliSumSection", prop.Key, prop.Line, prop.Value)
+		case "Cache Ratio":
+			// e.g. `10% Read / 90% Write`
+			read, write, _ := strings.Cut(prop.Value, "/")
+			tmp.CacheRatioRead = errs.parseFloat("parseSsacliSumSection", prop.Key, prop.Line, strings.TrimSuffix(firstField(read), "%"))
+			tmp.CacheRatioWrite = errs.parseFloat("parseSsacliSumSection", prop.Key, prop.Line, strings.TrimSuffix(firstField(write), "%"))
+		case "Drive Write Cache":
+			tmp.DriveWriteCache = prop.Value
+		case "No-Battery Write Cache":
+			tmp.NoBatteryWriteCache = prop.Value
+		case "Rebuild Priority":
+			tmp.RebuildPriority = prop.Value
+		case "Expand Priority":
+			tmp.ExpandPriority = prop.Value
+		case "Surface Scan Mode":
+			tmp.SurfaceScanMode = prop.Value
+		case "Surface Scan Delay":
+			// e.g. `3 secs`
+			tmp.SurfaceScanDelay = errs.parseFloat("parseSsacliSumSection", prop.Key, prop.Line, firstField(prop.Value))
+		case "Spare Activation Mode":
+			tmp.SpareActivationMode = prop.Value
+		case "Current Power Mode":
+			tmp.PowerMode = prop.Value
+		case "Survival Mode":
+			tmp.SurvivalMode = prop.Value
+		case "Number of Ports":
+			// e.g. `1 Internal only`
+			tmp.Ports = errs.parseFloat("parseSsacliSumSection", prop.Key, prop.Line, firstField(prop.Value))
 		}
 	}
 
-	data := SsacliSum{
-		ContNumber:    contNumber,
-		SsacliSumData: sumData,
-	}
-	return &data
+	return tmp, errs
 }
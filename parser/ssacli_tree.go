@@ -0,0 +1,133 @@
+package parser
+
+import (
+	"strings"
+)
+
+// SsacliSection is a block of ssacli output: a header line, such as
+// `Smart Array P440ar in Slot 0 (Embedded)`, `Array A` or
+// `physicaldrive 1I:1:1`, and the lines indented below it. Lines holding a
+// `key: value` pair become properties of the section, and lines followed by
+// more indented lines become child sections.
+type SsacliSection struct {
+	Header   string
+	Line     int
+	Props    []SsacliProp
+	Children []*SsacliSection
+}
+
+// SsacliProp is a `key: value` line of ssacli output
+type SsacliProp struct {
+	Key   string
+	Value string
+	Line  int
+}
+
+// ParseSsacliTree return the tree of sections of any ssacli output. The
+// returned root section has no header, its children are the sections that
+// are not indented, usually one per controller.
+func ParseSsacliTree(s string) *SsacliSection {
+	type level struct {
+		indent  int
+		section *SsacliSection
+	}
+
+	root := &SsacliSection{}
+	stack := []level{{indent: -1, section: root}}
+
+	lines := strings.Split(s, "\n")
+	for n, line := range lines {
+		text := trim(line)
+		if text == "" {
+			continue
+		}
+		indent := indentOf(line)
+
+		for stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1].section
+
+		// A line is a header when the next non-empty line is indented
+		// deeper, even if it looks like a property, e.g. `Logical Drive: 1`
+		if nextIndent(lines[n+1:]) > indent {
+			section := &SsacliSection{Header: text, Line: n + 1}
+			parent.Children = append(parent.Children, section)
+			stack = append(stack, level{indent: indent, section: section})
+			continue
+		}
+
+		if key, value, ok := cutProp(text); ok {
+			parent.Props = append(parent.Props, SsacliProp{Key: key, Value: value, Line: n + 1})
+			continue
+		}
+
+		parent.Children = append(parent.Children, &SsacliSection{Header: text, Line: n + 1})
+	}
+
+	return root
+}
+
+// Get returns the property with the given key, if the section holds it
+func (s *SsacliSection) Get(key string) (SsacliProp, bool) {
+	for _, prop := range s.Props {
+		if prop.Key == key {
+			return prop, true
+		}
+	}
+	return SsacliProp{}, false
+}
+
+// Value returns the value of the property with the given key, or an empty
+// string if the section does not hold it
+func (s *SsacliSection) Value(key string) string {
+	prop, _ := s.Get(key)
+	return prop.Value
+}
+
+// Find returns the sections below s, at any depth, whose header starts with
+// prefix, in the order they appear in the output
+func (s *SsacliSection) Find(prefix string) []*SsacliSection {
+	found := make([]*SsacliSection, 0)
+	s.Walk(func(section *SsacliSection) {
+		if section != s && strings.HasPrefix(section.Header, prefix) {
+			found = append(found, section)
+		}
+	})
+	return found
+}
+
+// Walk calls fn for s and every section below it, depth first
+func (s *SsacliSection) Walk(fn func(*SsacliSection)) {
+	fn(s)
+	for _, child := range s.Children {
+		child.Walk(fn)
+	}
+}
+
+// cutProp splits a `key: value` line. A trailing colon denotes a property
+// with an empty value.
+func cutProp(text string) (string, string, bool) {
+	if key, value, ok := strings.Cut(text, ": "); ok {
+		return trim(key), trim(value), true
+	}
+	if strings.HasSuffix(text, ":") {
+		return strings.TrimSuffix(text, ":"), "", true
+	}
+	return "", "", false
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// nextIndent returns the indentation of the first non-empty line, or -1 if
+// there is none
+func nextIndent(lines []string) int {
+	for _, line := range lines {
+		if trim(line) != "" {
+			return indentOf(line)
+		}
+	}
+	return -1
+}
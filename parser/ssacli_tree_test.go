@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"slices"
+	"testing"
+)
+
+const treeOutput = `
+Smart Array P440ar in Slot 0 (Embedded)
+   Slot: 0
+   Cache Board Present: True
+   Encryption:
+
+   Array: A
+      Status: OK
+
+      Logical Drive: 1
+         Size: 1.1 TB
+         Mirror Group 1:
+            physicaldrive 1I:1:1 (port 1I:box 1:bay 1, SAS HDD, 600 GB, OK)
+
+      physicaldrive 1I:1:1
+         Port: 1I
+         Status: OK
+
+Smart HBA H240 in Slot 1
+   Slot: 1
+`
+
+func TestParseSsacliTree(t *testing.T) {
+	root := ParseSsacliTree(treeOutput)
+
+	if root.Header != "" {
+		t.Errorf("root header = %q, want empty", root.Header)
+	}
+	headers := make([]string, 0)
+	for _, child := range root.Children {
+		headers = append(headers, child.Header)
+	}
+	want := []string{"Smart Array P440ar in Slot 0 (Embedded)", "Smart HBA H240 in Slot 1"}
+	if !slices.Equal(headers, want) {
+		t.Fatalf("controllers = %q, want %q", headers, want)
+	}
+
+	con := root.Children[0]
+	tests := []struct {
+		key   string
+		value string
+		ok    bool
+	}{
+		{key: "Slot", value: "0", ok: true},
+		{key: "Cache Board Present", value: "True", ok: true},
+		{key: "Encryption", value: "", ok: true},
+		{key: "Status", ok: false},
+	}
+	for _, tt := range tests {
+		prop, ok := con.Get(tt.key)
+		if ok != tt.ok || prop.Value != tt.value {
+			t.Errorf("Get(%q) = %q, %v, want %q, %v", tt.key, prop.Value, ok, tt.value, tt.ok)
+		}
+	}
+	if prop, _ := con.Get("Slot"); prop.Line != 3 {
+		t.Errorf("Slot line = %d, want 3", prop.Line)
+	}
+
+	arrays := con.Find("Array")
+	if len(arrays) != 1 || arrays[0].Value("Status") != "OK" {
+		t.Fatalf("Find(Array) = %+v, want array A with status OK", arrays)
+	}
+
+	// `Logical Drive: 1` looks like a property but is followed by more
+	// indented lines, so it is a section
+	logDisks := arrays[0].Find("Logical Drive")
+	if len(logDisks) != 1 || logDisks[0].Value("Size") != "1.1 TB" {
+		t.Fatalf("Find(Logical Drive) = %+v, want logical drive 1", logDisks)
+	}
+	if _, ok := arrays[0].Get("Logical Drive"); ok {
+		t.Errorf("Logical Drive parsed as a property")
+	}
+
+	// The drive is listed under the mirror group and described under the
+	// array, Find returns both
+	physDisks := arrays[0].Find("physicaldrive")
+	if len(physDisks) != 2 {
+		t.Fatalf("Find(physicaldrive) returned %d sections, want 2", len(physDisks))
+	}
+	if len(physDisks[0].Props) != 0 || physDisks[1].Value("Port") != "1I" {
+		t.Errorf("Find(physicaldrive) = %+v, want the listing then the detail", physDisks)
+	}
+}
+
+func TestSectionID(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "physicaldrive 1I:1:1", want: "1I:1:1"},
+		{header: "physicaldrive 1I:1:1 (port 1I:box 1:bay 1, SAS HDD, 600 GB, OK)", want: "1I:1:1"},
+		{header: "Logical Drive: 1", want: "1"},
+		{header: "Array A", want: "A"},
+		{header: "Array: B", want: "B"},
+		{header: "Unassigned", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := sectionID(tt.header); got != tt.want {
+			t.Errorf("sectionID(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}